@@ -1,10 +1,14 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
+	"hakari-bot/internal/settings"
 	"hakari-bot/internal/voice"
 	"log/slog"
 	"os/exec"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -13,16 +17,23 @@ import (
 // Definição dos comandos
 func GetCommands() []*discordgo.ApplicationCommand {
 	var minVolume float64 = 0
+	var minLoops float64 = 0   // 0 = infinito
+	var minSeconds float64 = 0 // Inatividade e crossfade (0 = sair na hora / desligado)
+	var minFade float64 = 0    // 0 = corte seco
+	var minVote float64 = 0    // Ouvintes e porcentagem da votação
+	var minPosition float64 = 1
+	var manageServer int64 = discordgo.PermissionManageServer
 	return []*discordgo.ApplicationCommand{
 		{
 			Name:        "jackpot",
 			Description: "Kinji Hakari expande seu domínio.",
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Type:        discordgo.ApplicationCommandOptionNumber,
+					Type:        discordgo.ApplicationCommandOptionInteger,
 					Name:        "quantas-vezes",
 					Description: "Quantas vezes repetir? (Vazio = Infinito)",
 					Required:    false,
+					MinValue:    &minLoops,
 				},
 				{
 					Type:        discordgo.ApplicationCommandOptionInteger,
@@ -30,7 +41,364 @@ func GetCommands() []*discordgo.ApplicationCommand {
 					Description: "Volume da música (0-200, Padrão: 100)",
 					Required:    false,
 					MinValue:    &minVolume,
-					MaxValue:    100,
+					MaxValue:    voice.MaxVolume,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "inicio",
+					Description: "Começar a partir de (mm:ss), só no primeiro loop",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "efeito",
+					Description: "Toca já com um efeito (ex: nightcore)",
+					Required:    false,
+					Choices:     effectChoices(false),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "duracao",
+					Description: "Duração do domínio (ex: 4m11s); termina com fade-out",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "url",
+					Description: "Link direto para um arquivo de áudio (http/https)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionAttachment,
+					Name:        "arquivo",
+					Description: "Arquivo de áudio enviado junto com o comando",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "tocar",
+			Description: "Toca uma faixa da biblioteca, um link ou um arquivo enviado.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "faixa",
+					Description:  "Faixa da biblioteca",
+					Required:     false,
+					Autocomplete: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "quantas-vezes",
+					Description: "Quantas vezes repetir? (Vazio = Infinito)",
+					Required:    false,
+					MinValue:    &minLoops,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "volume",
+					Description: "Volume da música (0-200, Padrão: 100)",
+					Required:    false,
+					MinValue:    &minVolume,
+					MaxValue:    voice.MaxVolume,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "inicio",
+					Description: "Começar a partir de (mm:ss), só no primeiro loop",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "url",
+					Description: "Link direto para um arquivo de áudio (http/https)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionAttachment,
+					Name:        "arquivo",
+					Description: "Arquivo de áudio enviado junto com o comando",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "seek",
+			Description: "Pula para um ponto da música atual.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "posicao",
+					Description: "Posição no formato mm:ss",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "pause",
+			Description: "Pausa a música mantendo a posição.",
+		},
+		{
+			Name:        "resume",
+			Description: "Retoma a música de onde parou.",
+		},
+		{
+			Name:        "volume",
+			Description: "Altera o volume da música tocando agora.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "valor",
+					Description: "Volume (0-200, 100 = original)",
+					Required:    true,
+					MinValue:    &minVolume,
+					MaxValue:    voice.MaxVolume,
+				},
+			},
+		},
+		{
+			Name:        "pular",
+			Description: "Pula a faixa atual e segue para a próxima da fila.",
+		},
+		{
+			Name:        "fila",
+			Description: "Mostra a fila de reprodução.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "pagina",
+					Description: "Página da fila (Padrão: 1)",
+					Required:    false,
+					MinValue:    &minPosition,
+				},
+			},
+		},
+		{
+			Name:        "remover",
+			Description: "Remove uma faixa da fila.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "posicao",
+					Description: "Posição na fila (veja /fila)",
+					Required:    true,
+					MinValue:    &minPosition,
+				},
+			},
+		},
+		{
+			Name:        "mover",
+			Description: "Muda a posição de uma faixa na fila.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "de",
+					Description: "Posição atual na fila",
+					Required:    true,
+					MinValue:    &minPosition,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "para",
+					Description: "Nova posição na fila",
+					Required:    true,
+					MinValue:    &minPosition,
+				},
+			},
+		},
+		{
+			Name:        "limpar",
+			Description: "Esvazia a fila (a faixa atual continua tocando).",
+		},
+		{
+			Name:        "efeito",
+			Description: "Liga ou desliga um efeito no áudio tocando.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "nome",
+					Description: "Efeito (escolha de novo para desligar)",
+					Required:    true,
+					Choices:     effectChoices(true),
+				},
+			},
+		},
+		{
+			Name:        "loop",
+			Description: "Muda o modo de repetição (vazio = próximo modo).",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "modo",
+					Description: "Modo de loop",
+					Required:    false,
+					Choices:     loopChoices(),
+				},
+			},
+		},
+		{
+			Name:                     "config",
+			Description:              "Configurações do bot neste servidor.",
+			DefaultMemberPermissions: &manageServer,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "ver",
+					Description: "Mostra as configurações atuais.",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "volume",
+					Description: "Volume padrão das músicas.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "valor",
+							Description: "Volume (0-200)",
+							Required:    true,
+							MinValue:    &minVolume,
+							MaxValue:    voice.MaxVolume,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "loops",
+					Description: "Quantidade padrão de repetições.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "valor",
+							Description: "Repetições (0 = Infinito)",
+							Required:    true,
+							MinValue:    &minLoops,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "inatividade",
+					Description: "Tempo sozinho no canal antes de sair.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "segundos",
+							Description: "Segundos (0 = sai imediatamente)",
+							Required:    true,
+							MinValue:    &minSeconds,
+							MaxValue:    3600,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "crossfade",
+					Description: "Transição suave entre faixas diferentes da fila.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "segundos",
+							Description: "Duração da transição (0 = desligado)",
+							Required:    true,
+							MinValue:    &minSeconds,
+							MaxValue:    12,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "fade",
+					Description: "Fade-in/out ao iniciar, pausar, pular e parar.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "milissegundos",
+							Description: "Duração do fade (0 = corte seco)",
+							Required:    true,
+							MinValue:    &minFade,
+							MaxValue:    2000,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "canais",
+					Description: "Canais de voz onde o bot pode tocar (vazio = todos).",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "adicionar",
+							Description: "Permite um canal de voz.",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:         discordgo.ApplicationCommandOptionChannel,
+									Name:         "canal",
+									Description:  "Canal de voz",
+									Required:     true,
+									ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice},
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remover",
+							Description: "Remove um canal da lista.",
+							Options: []*discordgo.ApplicationCommandOption{
+								{
+									Type:         discordgo.ApplicationCommandOptionChannel,
+									Name:         "canal",
+									Description:  "Canal de voz",
+									Required:     true,
+									ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice},
+								},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "limpar",
+							Description: "Libera todos os canais.",
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "dj",
+					Description: "Cargo de DJ (vazio = remove).",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionRole,
+							Name:        "cargo",
+							Description: "Cargo de DJ",
+							Required:    false,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "votacao",
+					Description: "Regras de votação do /leave e /pular.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "ouvintes",
+							Description: "Acima de quantos ouvintes a votação usa botões",
+							Required:    false,
+							MinValue:    &minVote,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "porcentagem",
+							Description: "Votação passa com mais que esta porcentagem dos ouvintes",
+							Required:    false,
+							MinValue:    &minVote,
+							MaxValue:    99,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "resetar",
+					Description: "Volta todas as configurações ao padrão.",
 				},
 			},
 		},
@@ -53,14 +421,32 @@ func GetCommands() []*discordgo.ApplicationCommand {
 	}
 }
 
-type Bot struct{}
+type Bot struct {
+	settings *settings.Store
+	voice    *voice.Manager
+	votes    votes
+	panels   panels
+}
 
-func NewBot() *Bot {
-	return &Bot{}
+func NewBot(store *settings.Store, manager *voice.Manager) *Bot {
+	return &Bot{
+		settings: store,
+		voice:    manager,
+		votes:    votes{active: make(map[string]*vote)},
+		panels:   panels{active: make(map[string]*panel)},
+	}
 }
 
 // Handler de Interações (Slash Commands)
 func (b *Bot) InteractionHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		b.handleAutocomplete(s, i)
+		return
+	}
+	if i.Type == discordgo.InteractionMessageComponent {
+		b.handleComponent(s, i)
+		return
+	}
 	if i.Type != discordgo.InteractionApplicationCommand {
 		return
 	}
@@ -76,17 +462,137 @@ func (b *Bot) InteractionHandler(s *discordgo.Session, i *discordgo.InteractionC
 
 	log.Info("Comando recebido")
 
+	if !b.authorize(s, i, data.Name, log) {
+		return
+	}
+
 	switch data.Name {
 	case "jackpot":
 		b.handleJackpot(s, i, data, log)
+	case "tocar":
+		b.handleTocar(s, i, data, log)
+	case "seek":
+		b.handleSeek(s, i, data, log)
+	case "pause":
+		b.handlePause(s, i, log)
+	case "resume":
+		b.handleResume(s, i, log)
+	case "volume":
+		b.handleVolume(s, i, data, log)
+	case "pular":
+		b.handleSkip(s, i, log)
+	case "fila":
+		b.handleQueue(s, i, data)
+	case "remover":
+		b.handleRemove(s, i, data, log)
+	case "mover":
+		b.handleMove(s, i, data, log)
+	case "limpar":
+		b.handleClear(s, i, log)
+	case "efeito":
+		b.handleEffect(s, i, data, log)
+	case "loop":
+		b.handleLoop(s, i, data, log)
 	case "leave":
 		b.handleLeave(s, i, log)
+	case "config":
+		b.handleConfig(s, i, data, log)
 	case "status":
 		b.handleStatus(s, i, log)
 	}
 }
 
 func (b *Bot) handleJackpot(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	track, err := b.urlTrack(data)
+	if err != nil {
+		reply(s, i, "⚠️ "+err.Error(), true)
+		return
+	}
+	if track == nil {
+		track = b.voice.Library().Get(voice.JackpotTrackID)
+	}
+	b.play(s, i, data, track, false, log)
+}
+
+func (b *Bot) handleTocar(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	trackID := ""
+	for _, opt := range data.Options {
+		if opt.Name == "faixa" {
+			trackID = opt.StringValue()
+		}
+	}
+
+	track, err := b.urlTrack(data)
+	if err != nil {
+		reply(s, i, "⚠️ "+err.Error(), true)
+		return
+	}
+	switch {
+	case track != nil && trackID != "":
+		reply(s, i, "Escolha só uma opção: `faixa`, `url` ou `arquivo`.", true)
+		return
+	case track == nil && trackID == "":
+		reply(s, i, "Informe uma `faixa` da biblioteca, uma `url` ou um `arquivo`.", true)
+		return
+	case track == nil:
+		track = b.voice.Library().Get(trackID)
+	}
+
+	if track == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Faixa não encontrada.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	b.play(s, i, data, track, true, log)
+}
+
+// urlTrack monta a faixa avulsa a partir das opções url/arquivo (nil se nenhuma foi usada).
+// Anexos já trazem tipo e tamanho, então são recusados aqui sem baixar nada.
+func (b *Bot) urlTrack(data discordgo.ApplicationCommandInteractionData) (*voice.Track, error) {
+	var track *voice.Track
+	for _, opt := range data.Options {
+		if track != nil && (opt.Name == "url" || opt.Name == "arquivo") {
+			return nil, errors.New("use só uma opção: `url` ou `arquivo`")
+		}
+
+		switch opt.Name {
+		case "url":
+			t, err := b.voice.NewURLTrack(strings.TrimSpace(opt.StringValue()), "")
+			if err != nil {
+				return nil, err
+			}
+			track = t
+		case "arquivo":
+			id, _ := opt.Value.(string)
+			if data.Resolved == nil || data.Resolved.Attachments[id] == nil {
+				return nil, errors.New("anexo não encontrado")
+			}
+			att := data.Resolved.Attachments[id]
+			if err := voice.CheckContentType(att.ContentType); err != nil {
+				return nil, err
+			}
+			if limit := voice.DefaultHTTPLimits.MaxBytes; limit > 0 && int64(att.Size) > limit {
+				return nil, voice.ErrTooLarge
+			}
+			t, err := b.voice.NewURLTrack(att.URL, att.Filename)
+			if err != nil {
+				return nil, err
+			}
+			track = t
+		}
+	}
+	return track, nil
+}
+
+// play valida o contexto da interação, responde com o painel "tocando agora" e inicia o playback da faixa.
+// Com enqueue, a faixa entra no fim da fila em vez de interromper a atual.
+func (b *Bot) play(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, track *voice.Track, enqueue bool, log *slog.Logger) {
 	// Validações iniciais
 	guildID := i.GuildID
 	if guildID == "" {
@@ -117,33 +623,80 @@ func (b *Bot) handleJackpot(s *discordgo.Session, i *discordgo.InteractionCreate
 		return
 	}
 
-	// Verifica parametros
-	loops := 0
-	volume := 100
+	if track == nil {
+		log.Error("Faixa não carregada na biblioteca")
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: "⚠️ **Erro Crítico:** O áudio não foi carregado na memória."},
+		})
+		return
+	}
+
+	cfg := b.settings.Get(guildID)
+	if !cfg.IsChannelAllowed(userChannelID) {
+		reply(s, i, "Não posso tocar neste canal de voz. Veja `/config ver`.", true)
+		return
+	}
+
+	// Verifica parametros (padrões vêm das configurações do servidor)
+	loops := cfg.DefaultLoops
+	volume := cfg.DefaultVolume
+	var start, limit time.Duration
+	var effects []string
 
 	for _, opt := range data.Options {
 		switch opt.Name {
+		case "efeito":
+			effects = []string{opt.StringValue()}
+		case "duracao":
+			limit, err = parseLimit(opt.StringValue())
+			if err != nil || limit <= 0 {
+				reply(s, i, "Duração inválida: use algo como `4m11s` ou `4:11`.", true)
+				return
+			}
 		case "quantas-vezes":
-			loops = int(opt.FloatValue())
+			loops = int(opt.IntValue())
 		case "volume":
 			volume = int(opt.IntValue())
+		case "inicio":
+			if !track.Source.Seekable() {
+				reply(s, i, "Esta faixa não permite escolher o início.", true)
+				return
+			}
+			start, err = parseTimestamp(opt.StringValue())
+			if err != nil || (track.Duration > 0 && start >= track.Duration) {
+				reply(s, i, fmt.Sprintf("Início inválido: use mm:ss dentro da duração da faixa (%s).", formatDuration(track.Duration)), true)
+				return
+			}
 		}
 	}
 
-	// Responde com Embed
-	embed := &discordgo.MessageEmbed{
-		Title:       "Kinji Hakari expande seu domínio",
-		Description: "JACKPOT!",
-		Color:       0x7efba6, // Hex color
-		Image: &discordgo.MessageEmbedImage{
-			URL: "https://media.tenor.com/Rpk3q-OLFeYAAAAC/hakari-dance-hakari.gif",
-		},
+	item := &voice.QueueItem{Track: track, Loops: loops, Volume: volume, Start: start, RequestedBy: i.Member.User.ID}
+
+	// Se já estiver tocando, só adiciona à fila
+	if sess := b.voice.GetSession(guildID); enqueue && sess != nil && sess.IsPlaying() {
+		pos := sess.Enqueue(item)
+		log.Info("Faixa adicionada à fila", "track_id", track.ID, "position", pos)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("**%s** adicionada à fila (posição %d).", track.Title, pos),
+			},
+		})
+		return
 	}
 
+	// Responde com o painel (atualizado pelo runPanel depois que o playback começar)
+	progress := voice.Progress{Track: track, Position: start, Loop: 1, Loops: loops, Volume: volume, Effects: effects, TimeLimit: limit, TimeLeft: limit}
+	if sess := b.voice.GetSession(guildID); sess != nil && effects == nil {
+		progress.Effects = sess.Effects()
+	}
+	embed, components := b.renderPanel(progress)
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Embeds: []*discordgo.MessageEmbed{embed},
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
 		},
 	})
 	if err != nil {
@@ -152,24 +705,148 @@ func (b *Bot) handleJackpot(s *discordgo.Session, i *discordgo.InteractionCreate
 	}
 
 	// Lógica de Voz
-	sess, err := voice.GlobalManager.Join(s, guildID, userChannelID)
+	sess, err := b.voice.Join(guildID, userChannelID)
 	if err != nil {
 		log.Error("Erro ao conectar voz", "error", err)
 		return
 	}
 
+	sess.SetCrossfade(cfg.Crossfade())
+	sess.SetFade(cfg.Fade())
+
+	// Efeito pedido no comando substitui os da sessão
+	if effects != nil {
+		if err := sess.SetEffects(effects); err != nil {
+			log.Warn("Efeito inválido", "effects", effects, "error", err)
+		}
+	}
+
 	// Inicia Playback
-	if len(voice.AudioCache) == 0 {
-		slog.Error("Cache de áudio vazio!")
-		s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-			Content: "⚠️ **Erro Crítico:** O áudio não foi carregado na memória.",
-		})
-		voice.GlobalManager.Leave(guildID)
+	log.Info("Iniciando playback", "track_id", track.ID, "loops", loops, "volume", volume, "start", start, "duration", track.Duration, "limit", limit)
+	if enqueue {
+		sess.Enqueue(item)
+	} else {
+		// O /jackpot substitui o player, e com ele o prazo do domínio anterior
+		sess.PlayLoop(item, limit)
+	}
+	b.startPanel(s, guildID, i.Interaction)
+}
+
+// handleComponent roteia cliques em botões pelo prefixo do custom_id
+func (b *Bot) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
 		return
 	}
 
-	log.Info("Iniciando playback", "loops", loops, "volume", volume, "size", len(voice.AudioCache))
-	sess.PlayLoop(voice.AudioCache, loops, volume)
+	customID := i.MessageComponentData().CustomID
+	switch {
+	case strings.HasPrefix(customID, voteButtonPrefix):
+		b.handleVoteButton(s, i)
+	case strings.HasPrefix(customID, panelButtonPrefix):
+		b.handlePanelButton(s, i)
+	}
+}
+
+// handleAutocomplete sugere faixas da biblioteca conforme o usuário digita
+func (b *Bot) handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	query := ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Focused {
+			query = opt.StringValue()
+		}
+	}
+
+	// O Discord aceita no máximo 25 sugestões
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, t := range b.voice.Library().Search(query, 25) {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s (%s)", t.Title, formatDuration(t.Duration)),
+			Value: t.ID,
+		})
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+	if err != nil {
+		slog.Warn("Erro ao responder autocomplete", "error", err)
+	}
+}
+
+// reply responde a interação com uma mensagem de texto simples
+func reply(s *discordgo.Session, i *discordgo.InteractionCreate, content string, ephemeral bool) {
+	var flags discordgo.MessageFlags
+	if ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content, Flags: flags},
+	})
+	if err != nil {
+		slog.Warn("Erro ao responder interação", "error", err)
+	}
+}
+
+// parseTimestamp interpreta "ss", "mm:ss" ou "hh:mm:ss"
+func parseTimestamp(value string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("formato inválido: %q", value)
+	}
+
+	var total time.Duration
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("formato inválido: %q", value)
+		}
+		total = total*60 + time.Duration(n)*time.Second
+	}
+	return total, nil
+}
+
+// parseLimit aceita durações no formato do Go ("4m11s", "90s") ou mm:ss
+func parseLimit(value string) (time.Duration, error) {
+	if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil {
+		return d, nil
+	}
+	return parseTimestamp(value)
+}
+
+// formatDuration formata a duração como mm:ss
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// formatTarget descreve o alvo da normalização de loudness
+func formatTarget(target float64) string {
+	if target == 0 {
+		return "Desligada"
+	}
+	return fmt.Sprintf("%.0f LUFS", target)
+}
+
+// formatLoudness mostra a loudness medida da faixa e o ganho aplicado para chegar ao alvo
+func formatLoudness(track *voice.Track, target float64) string {
+	if track.Loudness == 0 {
+		return "não medida"
+	}
+	if target == 0 {
+		return fmt.Sprintf("%.1f LUFS", track.Loudness)
+	}
+	return fmt.Sprintf("%.1f LUFS (%+.1f dB)", track.Loudness, track.NormalizationDB(target))
+}
+
+// formatLength formata a duração total de uma faixa ("--:--" quando desconhecida, como em streams)
+func formatLength(d time.Duration) string {
+	if d <= 0 {
+		return "--:--"
+	}
+	return formatDuration(d)
 }
 
 func (b *Bot) handleStatus(s *discordgo.Session, i *discordgo.InteractionCreate, log *slog.Logger) {
@@ -180,7 +857,7 @@ func (b *Bot) handleStatus(s *discordgo.Session, i *discordgo.InteractionCreate,
 	ffmpegStatus := "✅ Instalado"
 	path, err := exec.LookPath("ffmpeg")
 	if err != nil {
-		ffmpegStatus = "❌ Não encontrado"
+		ffmpegStatus = "➖ Não encontrado (opcional)"
 	} else {
 		ffmpegStatus += fmt.Sprintf(" (`%s`)", path)
 	}
@@ -191,58 +868,130 @@ func (b *Bot) handleStatus(s *discordgo.Session, i *discordgo.InteractionCreate,
 		Fields: []*discordgo.MessageEmbedField{
 			{Name: "Latência API", Value: fmt.Sprintf("%d ms", latency.Milliseconds()), Inline: true},
 			{Name: "FFmpeg", Value: ffmpegStatus, Inline: true},
+			{Name: "Decoder", Value: b.voice.Decoder().Name(), Inline: true},
+			{Name: "Normalização", Value: formatTarget(b.voice.TargetLUFS()), Inline: true},
 			{Name: "Goroutines", Value: fmt.Sprintf("%d", 0), Inline: true}, // Placeholder or actual runtime.NumGoroutine()
 		},
 	}
 
+	// Loudness da faixa tocando neste servidor
+	if sess := b.voice.GetSession(i.GuildID); sess != nil {
+		if item := sess.Current(); item != nil {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:  "Loudness",
+				Value: fmt.Sprintf("%s: %s", item.Track.Title, formatLoudness(item.Track, b.voice.TargetLUFS())),
+			})
+		}
+	}
+
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}},
 	})
 }
 
+func (b *Bot) handleSeek(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil || sess.Current() == nil {
+		reply(s, i, "Nada tocando no momento.", true)
+		return
+	}
+
+	pos, err := parseTimestamp(data.Options[0].StringValue())
+	if err != nil {
+		reply(s, i, "Posição inválida, use o formato mm:ss.", true)
+		return
+	}
+
+	if err := sess.Seek(pos); err != nil {
+		if errors.Is(err, voice.ErrNotSeekable) {
+			reply(s, i, "Esta faixa não permite seek.", true)
+			return
+		}
+		reply(s, i, "Posição fora da duração da faixa.", true)
+		return
+	}
+
+	log.Info("Seek", "position", pos)
+	reply(s, i, fmt.Sprintf("⏩ Indo para %s.", formatDuration(pos)), false)
+}
+
+func (b *Bot) handlePause(s *discordgo.Session, i *discordgo.InteractionCreate, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil || !sess.Pause() {
+		reply(s, i, "Nada tocando para pausar.", true)
+		return
+	}
+
+	log.Info("Playback pausado")
+	reply(s, i, "⏸️ Pausado. Use `/resume` para continuar.", false)
+}
+
+func (b *Bot) handleResume(s *discordgo.Session, i *discordgo.InteractionCreate, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil || !sess.Resume() {
+		reply(s, i, "Nada pausado no momento.", true)
+		return
+	}
+
+	log.Info("Playback retomado")
+	reply(s, i, "▶️ Retomado.", false)
+}
+
+func (b *Bot) handleVolume(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil || !sess.IsPlaying() {
+		reply(s, i, "Nada tocando no momento.", true)
+		return
+	}
+
+	volume := int(data.Options[0].IntValue())
+	old := sess.Volume()
+	sess.SetVolume(volume)
+
+	log.Info("Volume alterado", "old", old, "volume", volume)
+	reply(s, i, fmt.Sprintf("🔊 Volume: %d%% → %d%%", old, volume), false)
+}
+
 func (b *Bot) handleLeave(s *discordgo.Session, i *discordgo.InteractionCreate, log *slog.Logger) {
 	// Verifica opções
 	lazy := false
 	data := i.ApplicationCommandData()
-	if len(data.Options) > 0 {
-		for _, opt := range data.Options {
-			if opt.Name == "apos-musica" {
-				lazy = opt.BoolValue()
-			}
+	for _, opt := range data.Options {
+		if opt.Name == "apos-musica" {
+			lazy = opt.BoolValue()
 		}
 	}
 
+	msg, ok := b.leave(i.GuildID, lazy)
+	if !ok {
+		reply(s, i, msg, true)
+		return
+	}
+
+	log.Info("Leave executado", "lazy", lazy)
+	reply(s, i, msg, false)
+}
+
+// leave sai do canal (ou agenda a saída após a música, com lazy).
+// Compartilhado entre o comando e a votação.
+func (b *Bot) leave(guildID string, lazy bool) (string, bool) {
 	if lazy {
-		sess := voice.GlobalManager.GetSession(i.GuildID)
+		sess := b.voice.GetSession(guildID)
 		if sess == nil {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{Content: "Não estou em um canal de voz."},
-			})
-			return
+			return "Não estou em um canal de voz.", false
 		}
 
-		sess.SetLazyExit(true)
-		log.Info("Lazy Exit agendado")
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "Domínio será liberado após o fim da música.",
-			},
-		})
-		return
+		// Sem música tocando não há o que esperar: sai na hora
+		if sess.Drain() {
+			slog.Info("Lazy Exit agendado", "guild_id", guildID)
+			return "Domínio será liberado após o fim da música.", true
+		}
 	}
 
-	voice.GlobalManager.Leave(i.GuildID)
-	log.Info("Desconectou do canal de voz")
-
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "Kinji Hakari liberou seu domínio.",
-		},
-	})
+	b.voice.LeaveAfterFade(guildID)
+	slog.Info("Desconectou do canal de voz", "guild_id", guildID)
+	return "Kinji Hakari liberou seu domínio.", true
 }
 
 // VoiceStateUpdateHandler lida com eventos como "Fiquei sozinho no canal"
@@ -255,21 +1004,23 @@ func (b *Bot) VoiceStateUpdateHandler(s *discordgo.Session, v *discordgo.VoiceSt
 
 			// Se o bot estiver reconectando, ignoramos este evento de disconnect
 			// pois é esperado durante o processo de reconexão.
-			sess := voice.GlobalManager.GetSession(v.GuildID)
+			sess := b.voice.GetSession(v.GuildID)
 			if sess != nil && sess.IsReconnecting() {
 				slog.Info("Ignorando disconnect pois estamos reconectando...", "guild_id", v.GuildID)
 				return
 			}
 
-			voice.GlobalManager.Leave(v.GuildID)
+			// Sem fade: o bot já saiu do canal (kick ou desconexão), não há para onde enviar o fade-out
+			b.voice.Leave(v.GuildID)
 		}
 		return
 	}
 
 	// Lógica para sair se estiver sozinho
+	// Vale também para sessões pausadas: pausa longa sem ninguém ouvindo não segura o bot no canal.
 	// (Requer consulta à lista de membros do canal, simplificada aqui)
-	sess := voice.GlobalManager.GetSession(v.GuildID)
-	if sess != nil && v.BeforeUpdate != nil && sess.ChannelID == v.BeforeUpdate.ChannelID {
+	sess := b.voice.GetSession(v.GuildID)
+	if sess != nil && v.BeforeUpdate != nil && sess.ChannelID() == v.BeforeUpdate.ChannelID {
 		guild, err := s.State.Guild(v.GuildID)
 		if err != nil {
 			return
@@ -277,16 +1028,17 @@ func (b *Bot) VoiceStateUpdateHandler(s *discordgo.Session, v *discordgo.VoiceSt
 
 		userCount := 0
 		for _, vs := range guild.VoiceStates {
-			if vs.ChannelID == sess.ChannelID {
+			if vs.ChannelID == sess.ChannelID() {
 				userCount++
 			}
 		}
 
 		// Se userCount for 1, é só o bot
 		if userCount == 1 {
-			slog.Info("Bot sozinho no canal, agendando saída...", "guild_id", v.GuildID)
-			// Aguarda 5 segundos antes de sair (Debounce simples)
-			time.AfterFunc(5*time.Second, func() {
+			idleTimeout := b.settings.Get(v.GuildID).IdleTimeout()
+			slog.Info("Bot sozinho no canal, agendando saída...", "guild_id", v.GuildID, "timeout", idleTimeout)
+			// Aguarda o tempo de inatividade configurado antes de sair (Debounce simples)
+			b.voice.Clock().AfterFunc(idleTimeout, func() {
 				// Verifica novamente se ainda está sozinho
 				// Precisamos de uma nova referência ao guild atualizada
 				g, err := s.State.Guild(v.GuildID)
@@ -296,14 +1048,14 @@ func (b *Bot) VoiceStateUpdateHandler(s *discordgo.Session, v *discordgo.VoiceSt
 
 				count := 0
 				for _, vs := range g.VoiceStates {
-					if vs.ChannelID == sess.ChannelID {
+					if vs.ChannelID == sess.ChannelID() {
 						count++
 					}
 				}
 
 				if count == 1 {
 					slog.Info("Bot ainda sozinho, saindo.", "guild_id", v.GuildID)
-					voice.GlobalManager.Leave(v.GuildID)
+					b.voice.LeaveAfterFade(v.GuildID)
 				}
 			})
 		}
@@ -320,6 +1072,6 @@ func (b *Bot) VoiceServerUpdateHandler(s *discordgo.Session, v *discordgo.VoiceS
 		return
 	}
 
-	voice.GlobalManager.HandleServerUpdate(v)
+	b.voice.HandleServerUpdate(v)
 }
 
@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"fmt"
+	"hakari-bot/internal/settings"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleConfig trata o grupo /config (restrito a quem pode gerenciar o servidor)
+func (b *Bot) handleConfig(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	if i.GuildID == "" {
+		reply(s, i, "Use este comando em um servidor.", true)
+		return
+	}
+
+	sub := data.Options[0]
+	if sub.Name == "ver" {
+		b.showConfig(s, i, b.settings.Get(i.GuildID))
+		return
+	}
+
+	if sub.Name == "resetar" {
+		if err := b.settings.Reset(i.GuildID); err != nil {
+			log.Error("Erro ao resetar configurações", "error", err)
+			reply(s, i, "⚠️ Erro ao salvar as configurações.", true)
+			return
+		}
+		log.Info("Configurações resetadas")
+		b.showConfig(s, i, settings.Defaults())
+		return
+	}
+
+	var update func(*settings.GuildSettings)
+	switch sub.Name {
+	case "volume":
+		value := int(sub.Options[0].IntValue())
+		update = func(g *settings.GuildSettings) { g.DefaultVolume = value }
+	case "loops":
+		value := int(sub.Options[0].IntValue())
+		update = func(g *settings.GuildSettings) { g.DefaultLoops = value }
+	case "inatividade":
+		value := int(sub.Options[0].IntValue())
+		update = func(g *settings.GuildSettings) { g.IdleTimeoutSeconds = value }
+	case "crossfade":
+		value := int(sub.Options[0].IntValue())
+		update = func(g *settings.GuildSettings) { g.CrossfadeSeconds = value }
+	case "fade":
+		value := int(sub.Options[0].IntValue())
+		update = func(g *settings.GuildSettings) { g.FadeMillis = value }
+	case "dj":
+		roleID := ""
+		if len(sub.Options) > 0 {
+			roleID = sub.Options[0].RoleValue(nil, "").ID
+		}
+		update = func(g *settings.GuildSettings) { g.DJRoleID = roleID }
+	case "votacao":
+		update = func(g *settings.GuildSettings) {
+			for _, opt := range sub.Options {
+				switch opt.Name {
+				case "ouvintes":
+					g.VoteThreshold = int(opt.IntValue())
+				case "porcentagem":
+					g.VotePercent = int(opt.IntValue())
+				}
+			}
+		}
+	case "canais":
+		action := sub.Options[0]
+		switch action.Name {
+		case "adicionar":
+			channelID := action.Options[0].ChannelValue(nil).ID
+			update = func(g *settings.GuildSettings) {
+				if !slices.Contains(g.AllowedChannels, channelID) {
+					g.AllowedChannels = append(g.AllowedChannels, channelID)
+				}
+			}
+		case "remover":
+			channelID := action.Options[0].ChannelValue(nil).ID
+			update = func(g *settings.GuildSettings) {
+				g.AllowedChannels = slices.DeleteFunc(g.AllowedChannels, func(id string) bool { return id == channelID })
+			}
+		case "limpar":
+			update = func(g *settings.GuildSettings) { g.AllowedChannels = nil }
+		}
+	}
+
+	if update == nil {
+		reply(s, i, "Opção desconhecida.", true)
+		return
+	}
+
+	cfg, err := b.settings.Update(i.GuildID, update)
+	if err != nil {
+		log.Error("Erro ao salvar configurações", "error", err)
+		reply(s, i, "⚠️ Erro ao salvar as configurações.", true)
+		return
+	}
+
+	// Crossfade e fade valem também para a sessão tocando agora
+	if sess := b.voice.GetSession(i.GuildID); sess != nil {
+		sess.SetCrossfade(cfg.Crossfade())
+		sess.SetFade(cfg.Fade())
+	}
+
+	log.Info("Configurações atualizadas", "option", sub.Name)
+	b.showConfig(s, i, cfg)
+}
+
+// showConfig responde com um embed das configurações do servidor
+func (b *Bot) showConfig(s *discordgo.Session, i *discordgo.InteractionCreate, cfg settings.GuildSettings) {
+	loops := "∞"
+	if cfg.DefaultLoops > 0 {
+		loops = fmt.Sprintf("%d", cfg.DefaultLoops)
+	}
+
+	channels := "Todos"
+	if len(cfg.AllowedChannels) > 0 {
+		mentions := make([]string, len(cfg.AllowedChannels))
+		for idx, id := range cfg.AllowedChannels {
+			mentions[idx] = "<#" + id + ">"
+		}
+		channels = strings.Join(mentions, ", ")
+	}
+
+	crossfade := "Desligado"
+	if cfg.CrossfadeSeconds > 0 {
+		crossfade = fmt.Sprintf("%ds", cfg.CrossfadeSeconds)
+	}
+
+	fade := "Desligado"
+	if cfg.FadeMillis > 0 {
+		fade = fmt.Sprintf("%dms", cfg.FadeMillis)
+	}
+
+	dj := "Nenhum"
+	if cfg.DJRoleID != "" {
+		dj = "<@&" + cfg.DJRoleID + ">"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Configurações do Servidor",
+		Color: 0x3498db,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Volume padrão", Value: fmt.Sprintf("%d%%", cfg.DefaultVolume), Inline: true},
+			{Name: "Loops padrão", Value: loops, Inline: true},
+			{Name: "Inatividade", Value: fmt.Sprintf("%ds", cfg.IdleTimeoutSeconds), Inline: true},
+			{Name: "Crossfade", Value: crossfade, Inline: true},
+			{Name: "Fade", Value: fade, Inline: true},
+			{Name: "Canais permitidos", Value: channels},
+			{Name: "Cargo DJ", Value: dj},
+			{Name: "Votação", Value: fmt.Sprintf("Botões acima de %d ouvintes, passa com mais de %d%%", cfg.VoteThreshold, cfg.VotePercent)},
+		},
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
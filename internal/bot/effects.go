@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"fmt"
+	"hakari-bot/internal/voice"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Escolha do /efeito que desliga todos os efeitos
+const effectNone = "nenhum"
+
+// effectChoices monta as opções do /efeito a partir dos efeitos disponíveis
+func effectChoices(withNone bool) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, fx := range voice.AvailableEffects() {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s: %s", fx.Name, fx.Description),
+			Value: fx.Name,
+		})
+	}
+	if withNone {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: "nenhum: Desliga todos os efeitos", Value: effectNone})
+	}
+	return choices
+}
+
+func (b *Bot) handleEffect(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil {
+		reply(s, i, "Nada tocando no momento.", true)
+		return
+	}
+
+	name := data.Options[0].StringValue()
+	if name == effectNone {
+		sess.ClearEffects()
+		log.Info("Efeitos desligados")
+		reply(s, i, "🎛️ Efeitos desligados.", false)
+		return
+	}
+
+	on, err := sess.ToggleEffect(name)
+	if err != nil {
+		reply(s, i, "Efeito desconhecido.", true)
+		return
+	}
+
+	log.Info("Efeito alterado", "effect", name, "enabled", on, "effects", sess.Effects())
+	state := "desligado"
+	if on {
+		state = "ligado"
+	}
+	reply(s, i, fmt.Sprintf("🎛️ **%s** %s. Ativos: %s.", name, state, formatEffects(sess.Effects())), false)
+}
+
+// formatEffects lista os efeitos ativos ("nenhum" se vazio)
+func formatEffects(effects []string) string {
+	if len(effects) == 0 {
+		return "nenhum"
+	}
+	return strings.Join(effects, ", ")
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"fmt"
+	"hakari-bot/internal/voice"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Emoji e descrição de cada modo de loop
+var loopModeInfo = map[voice.LoopMode]struct{ emoji, description string }{
+	voice.LoopOff:     {"➡️", "cada faixa toca as vezes pedidas e a fila segue"},
+	voice.LoopOne:     {"🔂", "repete a faixa atual para sempre"},
+	voice.LoopQueue:   {"🔁", "as faixas terminadas voltam para o fim da fila"},
+	voice.LoopShuffle: {"🔀", "repete a fila em ordem aleatória"},
+}
+
+// loopChoices monta as opções do /loop a partir dos modos disponíveis
+func loopChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, mode := range voice.LoopModes() {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("%s: %s", mode, loopModeInfo[mode].description),
+			Value: mode.String(),
+		})
+	}
+	return choices
+}
+
+func (b *Bot) handleLoop(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil || !sess.IsPlaying() {
+		reply(s, i, "Nada tocando no momento.", true)
+		return
+	}
+
+	// Sem modo, avança para o próximo (como o botão do painel)
+	var mode voice.LoopMode
+	if len(data.Options) == 0 {
+		mode = sess.CycleLoopMode()
+	} else {
+		var err error
+		if mode, err = voice.ParseLoopMode(data.Options[0].StringValue()); err != nil {
+			reply(s, i, "Modo de loop desconhecido.", true)
+			return
+		}
+		sess.SetLoopMode(mode)
+	}
+
+	log.Info("Modo de loop alterado", "mode", mode.String())
+	msg := fmt.Sprintf("%s Loop: **%s** (%s).", loopModeInfo[mode].emoji, mode, loopModeInfo[mode].description)
+	if progress, ok := sess.Progress(); ok {
+		msg += fmt.Sprintf(" Faixa atual: %s.", formatLoop(progress))
+	}
+	reply(s, i, msg, false)
+}
+
+// formatLoop mostra o contador de loops da faixa atual e o modo ("2/3", "1/∞ · 🔂 faixa")
+func formatLoop(progress voice.Progress) string {
+	counter := fmt.Sprintf("%d/∞", progress.Loop)
+	if progress.Loops > 0 && progress.Mode != voice.LoopOne {
+		counter = fmt.Sprintf("%d/%d", progress.Loop, progress.Loops)
+	}
+	if progress.Mode == voice.LoopOff {
+		return counter
+	}
+	return fmt.Sprintf("%s · %s %s", counter, loopModeInfo[progress.Mode].emoji, progress.Mode)
+}
@@ -0,0 +1,290 @@
+package bot
+
+import (
+	"fmt"
+	"hakari-bot/internal/voice"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Prefixo do custom_id dos botões do painel ("panel:pause")
+const panelButtonPrefix = "panel:"
+
+const (
+	panelUpdateInterval = 5 * time.Second
+	// O token de uma interação vale 15 minutos; paramos de editar um pouco antes.
+	// Qualquer clique nos botões renova o token.
+	panelTokenTTL  = 14 * time.Minute
+	panelBarWidth  = 16
+	panelVolumeGap = 10
+)
+
+// Cada botão do painel equivale a um comando (usado na checagem de permissão)
+var panelCommands = map[string]string{
+	"pause":  "pause",
+	"resume": "resume",
+	"skip":   "pular",
+	"stop":   "leave",
+	"loop":   "loop",
+	"voldn":  "volume",
+	"volup":  "volume",
+}
+
+// panel é a mensagem "tocando agora" de um servidor
+type panel struct {
+	interaction *discordgo.Interaction // Interação cujo token edita a mensagem
+	issued      time.Time
+	stop        chan struct{}
+	mu          sync.Mutex
+}
+
+// panels guarda o painel ativo de cada servidor
+type panels struct {
+	active map[string]*panel
+	mu     sync.Mutex
+}
+
+func (p *panel) token() (*discordgo.Interaction, time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.interaction, p.issued
+}
+
+func (p *panel) refresh(interaction *discordgo.Interaction) {
+	p.mu.Lock()
+	p.interaction = interaction
+	p.issued = time.Now()
+	p.mu.Unlock()
+}
+
+// startPanel registra a resposta da interação como painel do servidor e inicia as atualizações.
+// Um painel anterior perde os botões.
+func (b *Bot) startPanel(s *discordgo.Session, guildID string, interaction *discordgo.Interaction) {
+	p := &panel{interaction: interaction, issued: time.Now(), stop: make(chan struct{})}
+
+	b.panels.mu.Lock()
+	old := b.panels.active[guildID]
+	b.panels.active[guildID] = p
+	b.panels.mu.Unlock()
+
+	if old != nil {
+		close(old.stop)
+		oldInteraction, _ := old.token()
+		s.InteractionResponseEdit(oldInteraction, &discordgo.WebhookEdit{Components: &[]discordgo.MessageComponent{}})
+	}
+
+	go b.runPanel(s, guildID, p)
+}
+
+// endPanel remove o painel do servidor sem editar a mensagem
+func (b *Bot) endPanel(guildID string, p *panel) {
+	b.panels.mu.Lock()
+	defer b.panels.mu.Unlock()
+
+	if b.panels.active[guildID] == p {
+		delete(b.panels.active, guildID)
+		close(p.stop)
+	}
+}
+
+// runPanel atualiza barra de progresso e contadores até o playback terminar
+func (b *Bot) runPanel(s *discordgo.Session, guildID string, p *panel) {
+	ticker := time.NewTicker(panelUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		interaction, issued := p.token()
+
+		sess := b.voice.GetSession(guildID)
+		if sess == nil || !sess.IsPlaying() {
+			b.endPanel(guildID, p)
+			content := "Kinji Hakari liberou seu domínio."
+			s.InteractionResponseEdit(interaction, &discordgo.WebhookEdit{
+				Content:    &content,
+				Embeds:     &[]*discordgo.MessageEmbed{},
+				Components: &[]discordgo.MessageComponent{},
+			})
+			return
+		}
+
+		// Ainda conectando ou token expirado: espera o próximo tick
+		progress, ok := sess.Progress()
+		if !ok || time.Since(issued) > panelTokenTTL {
+			continue
+		}
+
+		embed, components := b.renderPanel(progress)
+		_, err := s.InteractionResponseEdit(interaction, &discordgo.WebhookEdit{
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &components,
+		})
+		if err != nil {
+			slog.Warn("Erro ao atualizar painel", "guild_id", guildID, "error", err)
+		}
+	}
+}
+
+// handlePanelButton executa a ação do botão e redesenha o painel
+func (b *Bot) handlePanelButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	action := strings.TrimPrefix(i.MessageComponentData().CustomID, panelButtonPrefix)
+	command, ok := panelCommands[action]
+	if !ok {
+		return
+	}
+
+	log := slog.With("command", command, "button", action, "user_id", i.Member.User.ID, "guild_id", i.GuildID)
+	if !b.authorize(s, i, command, log) {
+		return
+	}
+
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil {
+		updateMessage(s, i, "Kinji Hakari liberou seu domínio.")
+		return
+	}
+
+	switch action {
+	case "pause":
+		sess.Pause()
+	case "resume":
+		sess.Resume()
+	case "skip":
+		sess.Skip()
+	case "loop":
+		sess.CycleLoopMode()
+	case "voldn":
+		sess.SetVolume(sess.Volume() - panelVolumeGap)
+	case "volup":
+		sess.SetVolume(sess.Volume() + panelVolumeGap)
+	case "stop":
+		b.panels.mu.Lock()
+		p := b.panels.active[i.GuildID]
+		b.panels.mu.Unlock()
+		if p != nil {
+			b.endPanel(i.GuildID, p)
+		}
+		msg, _ := b.leave(i.GuildID, false)
+		log.Info("Painel: parar")
+		updateMessage(s, i, msg)
+		return
+	}
+	log.Info("Painel: ação executada")
+
+	progress, ok := sess.Progress()
+	if !ok {
+		updateMessage(s, i, "Nada tocando no momento.")
+		return
+	}
+
+	embed, components := b.renderPanel(progress)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Warn("Erro ao atualizar painel", "error", err)
+		return
+	}
+
+	// O clique traz um token novo: o painel volta a ser editável por mais 15 minutos
+	b.panels.mu.Lock()
+	if p := b.panels.active[i.GuildID]; p != nil {
+		p.refresh(i.Interaction)
+	}
+	b.panels.mu.Unlock()
+}
+
+// renderPanel monta o embed e os botões do painel a partir do progresso
+func (b *Bot) renderPanel(progress voice.Progress) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	track := progress.Track
+
+	status := "▶️"
+	if progress.Paused {
+		status = "⏸️"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Tocando agora",
+		Description: fmt.Sprintf("**%s**\n%s %s", track.Title, status, progressBar(progress.Position, track.Duration)),
+		Color:       0x7efba6,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Loop", Value: formatLoop(progress), Inline: true},
+			{Name: "Volume", Value: fmt.Sprintf("%d%%", progress.Volume), Inline: true},
+			{Name: "Fila", Value: fmt.Sprintf("%d próxima(s)", progress.Queued), Inline: true},
+		},
+	}
+
+	if track.Loudness != 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Loudness", Value: formatLoudness(track, b.voice.TargetLUFS()), Inline: true})
+	}
+	if progress.TimeLimit > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Domínio", Value: formatDuration(progress.TimeLeft) + " restantes", Inline: true})
+	}
+	if len(progress.Effects) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Efeitos", Value: formatEffects(progress.Effects), Inline: true})
+	}
+
+	// A faixa do /jackpot mantém a expansão de domínio com o GIF
+	if track.ID == voice.JackpotTrackID {
+		embed.Title = "Kinji Hakari expande seu domínio"
+		if slices.Contains(progress.Effects, "nightcore") {
+			embed.Title += " (Nightcore)"
+		}
+		embed.Description = "JACKPOT!\n" + embed.Description
+		embed.Image = &discordgo.MessageEmbedImage{
+			URL: "https://media.tenor.com/Rpk3q-OLFeYAAAAC/hakari-dance-hakari.gif",
+		}
+	}
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				panelButton("pause", "⏸️", "Pausar", discordgo.SecondaryButton),
+				panelButton("resume", "▶️", "Retomar", discordgo.SecondaryButton),
+				panelButton("skip", "⏭️", "Pular", discordgo.PrimaryButton),
+				panelButton("stop", "⏹️", "Parar", discordgo.DangerButton),
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				panelButton("loop", "🔁", "Loop", discordgo.SecondaryButton),
+				panelButton("voldn", "🔉", fmt.Sprintf("-%d", panelVolumeGap), discordgo.SecondaryButton),
+				panelButton("volup", "🔊", fmt.Sprintf("+%d", panelVolumeGap), discordgo.SecondaryButton),
+			},
+		},
+	}
+	return embed, components
+}
+
+func panelButton(action, emoji, label string, style discordgo.ButtonStyle) discordgo.Button {
+	return discordgo.Button{
+		Label:    label,
+		Emoji:    &discordgo.ComponentEmoji{Name: emoji},
+		Style:    style,
+		CustomID: panelButtonPrefix + action,
+	}
+}
+
+// progressBar desenha "▬▬🔘▬▬ 01:23 / 02:45"
+func progressBar(pos, total time.Duration) string {
+	filled := 0
+	if total > 0 {
+		filled = min(int(int64(pos)*panelBarWidth/int64(total)), panelBarWidth-1)
+	}
+	bar := strings.Repeat("▬", filled) + "🔘" + strings.Repeat("▬", panelBarWidth-filled-1)
+	return fmt.Sprintf("%s `%s / %s`", bar, formatDuration(pos), formatLength(total))
+}
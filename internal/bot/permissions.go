@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"fmt"
+	"hakari-bot/internal/voice"
+	"log/slog"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// policy define quem pode executar um comando enquanto o bot está tocando
+type policy int
+
+const (
+	// policyOpen: qualquer membro
+	policyOpen policy = iota
+	// policyDJ: cargo de DJ, permissão Gerenciar Canais ou estar sozinho com o bot
+	policyDJ
+	// policyDJOrVote: como policyDJ, mas sem permissão o comando vira uma votação
+	policyDJOrVote
+)
+
+// Comandos que interferem na música dos outros. Os demais são abertos.
+var commandPolicies = map[string]policy{
+	"jackpot": policyDJ, // Interrompe o que estiver tocando
+	"leave":   policyDJOrVote,
+	"pular":   policyDJOrVote,
+	"pause":   policyDJ,
+	"resume":  policyDJ,
+	"seek":    policyDJ,
+	"volume":  policyDJ,
+	"remover": policyDJ,
+	"mover":   policyDJ,
+	"limpar":  policyDJ,
+	"efeito":  policyDJ,
+	"loop":    policyDJ, // /loop e botão de loop do painel
+}
+
+// authorize aplica a política do comando. Retorna true se o handler pode executar;
+// caso contrário a interação já foi respondida (negação ou voto registrado).
+func (b *Bot) authorize(s *discordgo.Session, i *discordgo.InteractionCreate, command string, log *slog.Logger) bool {
+	p := commandPolicies[command]
+	if p == policyOpen || i.GuildID == "" {
+		return true
+	}
+
+	// Sem sessão (ou, no /jackpot, sem nada tocando) não há ninguém para atrapalhar
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil || (command == "jackpot" && !sess.IsPlaying()) {
+		return true
+	}
+
+	if b.isPrivileged(s, i, sess) {
+		return true
+	}
+
+	if p == policyDJOrVote {
+		// Votação só pelo slash command (botões do painel não carregam as opções)
+		if i.Type != discordgo.InteractionApplicationCommand {
+			reply(s, i, fmt.Sprintf("🚫 Sem permissão. Use `/%s` para iniciar uma votação.", command), true)
+			return false
+		}
+		return b.startVote(s, i, sess, command, log)
+	}
+
+	log.Info("Comando negado por permissão")
+	reply(s, i, "🚫 Você precisa do cargo de DJ, da permissão Gerenciar Canais ou estar sozinho com o bot.", true)
+	return false
+}
+
+// isPrivileged verifica cargo de DJ, Gerenciar Canais ou se o membro está sozinho com o bot
+func (b *Bot) isPrivileged(s *discordgo.Session, i *discordgo.InteractionCreate, sess *voice.Session) bool {
+	if djRole := b.settings.Get(i.GuildID).DJRoleID; djRole != "" && slices.Contains(i.Member.Roles, djRole) {
+		return true
+	}
+
+	// Permissions já vem calculado na interação (inclui Administrador)
+	if i.Member.Permissions&(discordgo.PermissionManageChannels|discordgo.PermissionAdministrator) != 0 {
+		return true
+	}
+
+	users := listeners(s, i.GuildID, sess.ChannelID())
+	return len(users) == 1 && users[0] == i.Member.User.ID
+}
+
+// listeners retorna os IDs dos usuários (não bots) no canal de voz
+func listeners(s *discordgo.Session, guildID, channelID string) []string {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return nil
+	}
+
+	var users []string
+	for _, vs := range guild.VoiceStates {
+		if vs.ChannelID != channelID || vs.UserID == s.State.User.ID {
+			continue
+		}
+		if isBot(s, guildID, vs) {
+			continue
+		}
+		users = append(users, vs.UserID)
+	}
+	return users
+}
+
+// isBot verifica se o dono do voice state é um bot. O Member só vem preenchido em
+// parte dos eventos, então o membro é procurado no cache e, por último, na API.
+func isBot(s *discordgo.Session, guildID string, vs *discordgo.VoiceState) bool {
+	if vs.Member != nil && vs.Member.User != nil {
+		return vs.Member.User.Bot
+	}
+	if m, err := s.State.Member(guildID, vs.UserID); err == nil && m.User != nil {
+		return m.User.Bot
+	}
+	u, err := s.User(vs.UserID)
+	if err != nil {
+		slog.Warn("Erro buscando usuário do canal de voz", "user_id", vs.UserID, "error", err)
+		return false
+	}
+	return u.Bot
+}
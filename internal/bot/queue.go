@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"fmt"
+	"hakari-bot/internal/voice"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Itens exibidos por página no /fila
+const queuePageSize = 10
+
+func (b *Bot) handleSkip(s *discordgo.Session, i *discordgo.InteractionCreate, log *slog.Logger) {
+	msg, ok := b.skip(i.GuildID)
+	if !ok {
+		reply(s, i, msg, true)
+		return
+	}
+
+	log.Info("Faixa pulada")
+	reply(s, i, msg, false)
+}
+
+// skip pula a faixa atual. Compartilhado entre o comando e a votação.
+func (b *Bot) skip(guildID string) (string, bool) {
+	sess := b.voice.GetSession(guildID)
+	if sess == nil || !sess.Skip() {
+		return "Nada tocando no momento.", false
+	}
+	return "⏭️ Faixa pulada.", true
+}
+
+func (b *Bot) handleQueue(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil {
+		reply(s, i, "A fila está vazia.", true)
+		return
+	}
+	currentItem := sess.Current()
+	if currentItem == nil {
+		reply(s, i, "A fila está vazia.", true)
+		return
+	}
+
+	page := 1
+	for _, opt := range data.Options {
+		if opt.Name == "pagina" {
+			page = int(opt.IntValue())
+		}
+	}
+
+	queue := sess.Queue()
+	pages := (len(queue) + queuePageSize - 1) / queuePageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if page > pages {
+		page = pages
+	}
+
+	var lines strings.Builder
+	start := (page - 1) * queuePageSize
+	for idx := start; idx < len(queue) && idx < start+queuePageSize; idx++ {
+		fmt.Fprintf(&lines, "`%d.` %s\n", idx+1, describeItem(queue[idx]))
+	}
+	if lines.Len() == 0 {
+		lines.WriteString("Nenhuma faixa na fila.")
+	}
+
+	// O volume do item é o inicial; mostramos o atual da sessão
+	playing := *currentItem
+	playing.Volume = sess.Volume()
+	current := describeItem(&playing)
+	if sess.IsPaused() {
+		current = "⏸️ " + current
+	}
+
+	if progress, ok := sess.Progress(); ok {
+		current += "\nLoop: " + formatLoop(progress)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Fila de reprodução",
+		Color: 0x7efba6,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Tocando agora", Value: current},
+			{Name: fmt.Sprintf("Próximas (%d)", len(queue)), Value: lines.String()},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Página %d/%d", page, pages)},
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}},
+	})
+}
+
+func (b *Bot) handleRemove(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil {
+		reply(s, i, "Não estou em um canal de voz.", true)
+		return
+	}
+
+	pos := int(data.Options[0].IntValue())
+	item, err := sess.Remove(pos - 1)
+	if err != nil {
+		reply(s, i, fmt.Sprintf("Posição %d não existe na fila.", pos), true)
+		return
+	}
+
+	log.Info("Faixa removida da fila", "track_id", item.Track.ID, "position", pos)
+	reply(s, i, fmt.Sprintf("**%s** removida da fila.", item.Track.Title), false)
+}
+
+func (b *Bot) handleMove(s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil {
+		reply(s, i, "Não estou em um canal de voz.", true)
+		return
+	}
+
+	from, to := 0, 0
+	for _, opt := range data.Options {
+		switch opt.Name {
+		case "de":
+			from = int(opt.IntValue())
+		case "para":
+			to = int(opt.IntValue())
+		}
+	}
+
+	if err := sess.Move(from-1, to-1); err != nil {
+		reply(s, i, "Posição inválida na fila.", true)
+		return
+	}
+
+	log.Info("Faixa movida na fila", "from", from, "to", to)
+	reply(s, i, fmt.Sprintf("Faixa movida da posição %d para %d.", from, to), false)
+}
+
+func (b *Bot) handleClear(s *discordgo.Session, i *discordgo.InteractionCreate, log *slog.Logger) {
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil {
+		reply(s, i, "Não estou em um canal de voz.", true)
+		return
+	}
+
+	n := sess.Clear()
+	log.Info("Fila limpa", "removed", n)
+	reply(s, i, fmt.Sprintf("🧹 %d faixa(s) removida(s) da fila.", n), false)
+}
+
+// describeItem formata um item da fila para exibição
+func describeItem(item *voice.QueueItem) string {
+	loops := "∞"
+	if item.Loops > 0 {
+		loops = fmt.Sprintf("%dx", item.Loops)
+	}
+	return fmt.Sprintf("**%s** (%s) · %s · %d%%", item.Track.Title, formatLength(item.Track.Duration), loops, item.Volume)
+}
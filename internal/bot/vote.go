@@ -0,0 +1,262 @@
+package bot
+
+import (
+	"fmt"
+	"hakari-bot/internal/voice"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Tempo até uma votação sem maioria expirar
+const voteTimeout = 60 * time.Second
+
+// Prefixo do custom_id dos botões de votação ("vote:sim:<comando>:<id da votação>")
+const voteButtonPrefix = "vote:"
+
+// vote acumula os votos de um comando em andamento
+type vote struct {
+	id          string // ID da interação que abriu a votação, repetido nos botões
+	command     string
+	voters      map[string]bool
+	expires     time.Time
+	action      func() string          // Executada quando a votação passa, retorna a mensagem final
+	interaction *discordgo.Interaction // Mensagem com os botões (nil em votação por comando)
+}
+
+// votes guarda as votações por servidor, comando e alvo (ver voteKey)
+type votes struct {
+	active map[string]*vote
+	mu     sync.Mutex
+}
+
+// startVote registra o voto de quem chamou o comando. Retorna true se a ação pode
+// executar agora. Com poucos ouvintes o próprio comando conta como voto; acima do
+// limite configurado, uma mensagem com botões é publicada para o canal votar.
+func (b *Bot) startVote(s *discordgo.Session, i *discordgo.InteractionCreate, sess *voice.Session, command string, log *slog.Logger) bool {
+	users := listeners(s, i.GuildID, sess.ChannelID())
+	if !slices.Contains(users, i.Member.User.ID) {
+		reply(s, i, "🚫 Você precisa estar no canal de voz do bot para votar.", true)
+		return false
+	}
+
+	cfg := b.settings.Get(i.GuildID)
+	key := voteKey(i.GuildID, command, sess)
+
+	b.votes.mu.Lock()
+	b.votes.pruneLocked(b.voice.Clock().Now())
+	v, ok := b.votes.active[key]
+	if !ok {
+		v = &vote{id: i.ID, command: command, voters: make(map[string]bool), expires: b.voice.Clock().Now().Add(voteTimeout)}
+		b.votes.active[key] = v
+	}
+	v.voters[i.Member.User.ID] = true
+
+	count := countVotes(v, users)
+	needed := cfg.VotesNeeded(len(users))
+	if count >= needed {
+		delete(b.votes.active, key)
+		b.votes.mu.Unlock()
+		log.Info("Votação aprovada", "votes", count, "needed", needed)
+		return true
+	}
+
+	// Votação com botões: só na primeira chamada e com ouvintes acima do limite
+	buttons := !ok && len(users) > cfg.VoteThreshold
+	if buttons {
+		v.action = b.voteAction(i.GuildID, command, i.ApplicationCommandData())
+		v.interaction = i.Interaction
+	}
+	b.votes.mu.Unlock()
+
+	log.Info("Voto registrado", "votes", count, "needed", needed, "buttons", buttons)
+	if !buttons {
+		reply(s, i, fmt.Sprintf("🗳️ Voto registrado para `/%s` (%d/%d votos).", command, count, needed), false)
+		return false
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    voteMessage(command, count, needed),
+			Components: voteButtons(command, v.id),
+		},
+	})
+	if err != nil {
+		log.Error("Erro ao publicar votação", "error", err)
+		return false
+	}
+
+	// Encerra a mensagem se ninguém completar a votação
+	b.voice.Clock().AfterFunc(voteTimeout, func() {
+		b.votes.mu.Lock()
+		current, ok := b.votes.active[key]
+		if !ok || current != v {
+			b.votes.mu.Unlock()
+			return
+		}
+		delete(b.votes.active, key)
+		b.votes.mu.Unlock()
+
+		content := fmt.Sprintf("⌛ Votação para `/%s` expirou.", command)
+		s.InteractionResponseEdit(v.interaction, &discordgo.WebhookEdit{
+			Content:    &content,
+			Components: &[]discordgo.MessageComponent{},
+		})
+	})
+	return false
+}
+
+// handleVoteButton trata os cliques nos botões de votação
+func (b *Bot) handleVoteButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	// custom_id: vote:<sim|nao>:<comando>:<id da votação>
+	parts := strings.SplitN(strings.TrimPrefix(i.MessageComponentData().CustomID, voteButtonPrefix), ":", 3)
+	if len(parts) != 3 {
+		return
+	}
+	choice, command, id := parts[0], parts[1], parts[2]
+	log := slog.With("command", command, "user_id", i.Member.User.ID, "guild_id", i.GuildID)
+
+	sess := b.voice.GetSession(i.GuildID)
+	if sess == nil {
+		updateMessage(s, i, fmt.Sprintf("⌛ Votação para `/%s` encerrada.", command))
+		return
+	}
+	// Para /pular a chave inclui a faixa atual: botões de uma faixa que já saiu não acham a votação
+	key := voteKey(i.GuildID, command, sess)
+
+	b.votes.mu.Lock()
+	b.votes.pruneLocked(b.voice.Clock().Now())
+	v, ok := b.votes.active[key]
+	// Botões de uma votação anterior não contam para a que está aberta agora
+	if !ok || v.interaction == nil || v.id != id || b.voice.Clock().Now().After(v.expires) {
+		b.votes.mu.Unlock()
+		updateMessage(s, i, fmt.Sprintf("⌛ Votação para `/%s` encerrada.", command))
+		return
+	}
+
+	users := listeners(s, i.GuildID, sess.ChannelID())
+	if !slices.Contains(users, i.Member.User.ID) {
+		b.votes.mu.Unlock()
+		reply(s, i, "🚫 Você precisa estar no canal de voz do bot para votar.", true)
+		return
+	}
+
+	if choice == "sim" {
+		v.voters[i.Member.User.ID] = true
+	} else {
+		delete(v.voters, i.Member.User.ID)
+	}
+
+	count := countVotes(v, users)
+	needed := b.settings.Get(i.GuildID).VotesNeeded(len(users))
+	passed := count >= needed
+	if passed {
+		delete(b.votes.active, key)
+	}
+	b.votes.mu.Unlock()
+
+	log.Info("Voto registrado (botão)", "choice", choice, "votes", count, "needed", needed)
+	if !passed {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    voteMessage(command, count, needed),
+				Components: voteButtons(command, v.id),
+			},
+		})
+		return
+	}
+
+	log.Info("Votação aprovada")
+	updateMessage(s, i, "✅ Votação aprovada. "+v.action())
+}
+
+// voteAction monta a ação executada quando a votação do comando passa
+func (b *Bot) voteAction(guildID, command string, data discordgo.ApplicationCommandInteractionData) func() string {
+	switch command {
+	case "leave":
+		lazy := false
+		for _, opt := range data.Options {
+			if opt.Name == "apos-musica" {
+				lazy = opt.BoolValue()
+			}
+		}
+		return func() string {
+			msg, _ := b.leave(guildID, lazy)
+			return msg
+		}
+	case "pular":
+		return func() string {
+			msg, _ := b.skip(guildID)
+			return msg
+		}
+	}
+	return func() string { return "" }
+}
+
+// voteKey identifica a votação de um comando. Votos para /pular valem só para a
+// faixa que estava tocando quando a votação abriu.
+func voteKey(guildID, command string, sess *voice.Session) string {
+	key := guildID + ":" + command
+	if command == "pular" {
+		if p, ok := sess.Progress(); ok {
+			key += ":" + p.Track.ID
+		}
+	}
+	return key
+}
+
+// pruneLocked descarta as votações por comando expiradas; as com botões são
+// encerradas pelo timer que edita a mensagem. Deve ser chamado com mu travado.
+func (vs *votes) pruneLocked(now time.Time) {
+	for key, v := range vs.active {
+		if v.interaction == nil && now.After(v.expires) {
+			delete(vs.active, key)
+		}
+	}
+}
+
+// countVotes conta os votos de quem ainda está no canal
+func countVotes(v *vote, users []string) int {
+	count := 0
+	for _, id := range users {
+		if v.voters[id] {
+			count++
+		}
+	}
+	return count
+}
+
+func voteMessage(command string, count, needed int) string {
+	return fmt.Sprintf("🗳️ Votação para `/%s`: %d/%d votos. Expira em %ds.", command, count, needed, int(voteTimeout.Seconds()))
+}
+
+func voteButtons(command, id string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: "Sim", Emoji: &discordgo.ComponentEmoji{Name: "✅"}, Style: discordgo.SuccessButton, CustomID: voteButtonPrefix + "sim:" + command + ":" + id},
+				discordgo.Button{Label: "Não", Emoji: &discordgo.ComponentEmoji{Name: "❌"}, Style: discordgo.DangerButton, CustomID: voteButtonPrefix + "nao:" + command + ":" + id},
+			},
+		},
+	}
+}
+
+// updateMessage substitui a mensagem do componente por um texto sem botões
+func updateMessage(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		slog.Warn("Erro ao atualizar mensagem", "error", err)
+	}
+}
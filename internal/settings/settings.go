@@ -0,0 +1,191 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// GuildSettings são as preferências persistidas de um servidor
+type GuildSettings struct {
+	DefaultVolume      int      `json:"default_volume"`
+	DefaultLoops       int      `json:"default_loops"`        // 0 = infinito
+	IdleTimeoutSeconds int      `json:"idle_timeout_seconds"` // Tempo sozinho no canal antes de sair
+	AllowedChannels    []string `json:"allowed_channels,omitempty"`
+	DJRoleID           string   `json:"dj_role_id,omitempty"`
+	VoteThreshold      int      `json:"vote_threshold"`    // Acima de N ouvintes a votação usa botões
+	VotePercent        int      `json:"vote_percent"`      // Votação passa com mais que esta porcentagem
+	CrossfadeSeconds   int      `json:"crossfade_seconds"` // Transição entre faixas da fila (0 = desligado)
+	FadeMillis         int      `json:"fade_ms"`           // Fade-in/out ao iniciar, pausar, pular e parar (0 = corte seco)
+}
+
+// Defaults retorna as configurações usadas por servidores sem nada salvo
+func Defaults() GuildSettings {
+	return GuildSettings{
+		DefaultVolume:      100,
+		DefaultLoops:       0,
+		IdleTimeoutSeconds: 5,
+		VoteThreshold:      2,
+		VotePercent:        50,
+		FadeMillis:         250,
+	}
+}
+
+// IdleTimeout retorna o tempo de inatividade como Duration
+func (g GuildSettings) IdleTimeout() time.Duration {
+	return time.Duration(g.IdleTimeoutSeconds) * time.Second
+}
+
+// Crossfade retorna a duração do crossfade entre faixas como Duration
+func (g GuildSettings) Crossfade() time.Duration {
+	return time.Duration(g.CrossfadeSeconds) * time.Second
+}
+
+// Fade retorna a duração das rampas de volume como Duration
+func (g GuildSettings) Fade() time.Duration {
+	return time.Duration(g.FadeMillis) * time.Millisecond
+}
+
+// IsChannelAllowed indica se o bot pode tocar no canal (lista vazia = todos)
+func (g GuildSettings) IsChannelAllowed(channelID string) bool {
+	return len(g.AllowedChannels) == 0 || slices.Contains(g.AllowedChannels, channelID)
+}
+
+// VotesNeeded retorna quantos votos passam uma votação com n ouvintes
+func (g GuildSettings) VotesNeeded(n int) int {
+	return min(n, n*g.VotePercent/100+1)
+}
+
+// Store guarda as configurações de todos os servidores em um arquivo JSON local
+type Store struct {
+	path   string
+	guilds map[string]GuildSettings
+	mu     sync.RWMutex
+}
+
+// Open carrega o arquivo de configurações. Se ele não existir, começa vazio
+// e o arquivo é criado no primeiro Update.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		guilds: make(map[string]GuildSettings),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler configurações: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar configurações: %w", err)
+	}
+
+	// Campos ausentes (arquivos de versões antigas) ficam com o valor padrão
+	for guildID, msg := range raw {
+		g := Defaults()
+		if err := json.Unmarshal(msg, &g); err != nil {
+			return nil, fmt.Errorf("erro ao interpretar configurações do servidor %s: %w", guildID, err)
+		}
+		s.guilds[guildID] = g
+	}
+	return s, nil
+}
+
+// Get retorna as configurações do servidor (ou os padrões)
+func (s *Store) Get(guildID string) GuildSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.guilds[guildID]
+	if !ok {
+		return Defaults()
+	}
+	g.AllowedChannels = slices.Clone(g.AllowedChannels)
+	return g
+}
+
+// Update aplica fn sobre as configurações do servidor e salva o arquivo
+func (s *Store) Update(guildID string, fn func(*GuildSettings)) (GuildSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.guilds[guildID]
+	if !ok {
+		g = Defaults()
+	}
+	g.AllowedChannels = slices.Clone(g.AllowedChannels)
+	fn(&g)
+
+	old, existed := s.guilds[guildID]
+	s.guilds[guildID] = g
+	if err := s.saveLocked(); err != nil {
+		// Mantém memória e disco consistentes
+		if existed {
+			s.guilds[guildID] = old
+		} else {
+			delete(s.guilds, guildID)
+		}
+		return GuildSettings{}, err
+	}
+	return g, nil
+}
+
+// Reset apaga as configurações do servidor, voltando aos padrões
+func (s *Store) Reset(guildID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, existed := s.guilds[guildID]
+	if !existed {
+		return nil
+	}
+
+	delete(s.guilds, guildID)
+	if err := s.saveLocked(); err != nil {
+		s.guilds[guildID] = old
+		return err
+	}
+	return nil
+}
+
+// saveLocked grava o arquivo de forma atômica (arquivo temporário + rename).
+// Deve ser chamado com s.mu travado.
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.guilds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao serializar configurações: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("erro ao criar diretório de configurações: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".settings-*.json")
+	if err != nil {
+		return fmt.Errorf("erro ao salvar configurações: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao salvar configurações: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("erro ao salvar configurações: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("erro ao salvar configurações: %w", err)
+	}
+	return nil
+}
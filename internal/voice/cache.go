@@ -0,0 +1,118 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"layeh.com/gopus"
+)
+
+// Audio guarda uma faixa já decodificada na memória.
+// O PCM (s16le, 48kHz, estéreo) fica disponível para o estágio de ganho e os
+// frames Opus a 100% de volume são codificados uma única vez no carregamento.
+// Faixas Ogg/Opus e DCA usam os pacotes do arquivo direto (passthrough) e não
+// guardam PCM: o player decodifica o Opus quando precisa.
+type Audio struct {
+	Frames      [][]byte // Frames Opus de 20ms, volume 100%
+	Passthrough bool     // Frames vieram do arquivo, sem decode/re-encode
+
+	pcm []int16 // Amostras intercaladas, sempre múltiplo de frameSize*channels (nil em passthrough)
+}
+
+// NewAudio prepara a faixa a partir do arquivo bruto. Ogg/Opus e DCA com frames
+// de 20ms são usados em passthrough; o resto passa pelo decoder e é
+// codificado em Opus.
+func NewAudio(data []byte, decoder Decoder) (*Audio, error) {
+	header := data[:min(len(data), 64)]
+	switch detectFormat(header) {
+	case formatOggOpus:
+		if audio, err := newOggPassthrough(data); err == nil {
+			return audio, nil
+		}
+		// Frames fora do padrão do Discord: cai no caminho com re-encode
+	case formatDCA:
+		return NewDCAAudio(data)
+	}
+
+	pcm, err := decodePCM(data, decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	frames, err := encodeFrames(pcm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Audio{Frames: frames, pcm: pcm}, nil
+}
+
+// FrameCount retorna o número de frames de 20ms da faixa
+func (a *Audio) FrameCount() int {
+	return len(a.Frames)
+}
+
+// Duration retorna a duração total da faixa
+func (a *Audio) Duration() time.Duration {
+	return time.Duration(a.FrameCount()) * frameDuration
+}
+
+// frame retorna as amostras PCM do frame n (sem cópia). Não existe em faixas passthrough.
+func (a *Audio) frame(n int) []int16 {
+	size := frameSize * channels
+	return a.pcm[n*size : (n+1)*size]
+}
+
+// decodePCM decodifica o arquivo inteiro e converte para amostras.
+// O último frame é completado com silêncio.
+func decodePCM(data []byte, decoder Decoder) ([]int16, error) {
+	stream, err := decoder.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar áudio (%s): %w", decoder.Name(), err)
+	}
+
+	out, err := io.ReadAll(stream)
+	if closeErr := stream.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar áudio (%s): %w", decoder.Name(), err)
+	}
+
+	size := frameSize * channels
+	samples := len(out) / 2
+	if rem := samples % size; rem != 0 {
+		samples += size - rem
+	}
+	if samples == 0 {
+		return nil, fmt.Errorf("áudio decodificado está vazio")
+	}
+
+	pcm := make([]int16, samples)
+	for i := 0; i < len(out)/2; i++ {
+		pcm[i] = int16(binary.LittleEndian.Uint16(out[i*2:]))
+	}
+	return pcm, nil
+}
+
+// encodeFrames codifica todo o PCM em frames Opus de 20ms
+func encodeFrames(pcm []int16) ([][]byte, error) {
+	encoder, err := gopus.NewEncoder(frameRate, channels, gopus.Audio)
+	if err != nil {
+		return nil, fmt.Errorf("falha encoder: %v", err)
+	}
+
+	size := frameSize * channels
+	frames := make([][]byte, 0, len(pcm)/size)
+	for off := 0; off < len(pcm); off += size {
+		opusData, err := encoder.Encode(pcm[off:off+size], frameSize, maxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao codificar frame %d: %w", off/size, err)
+		}
+		frames = append(frames, opusData)
+	}
+	return frames, nil
+}
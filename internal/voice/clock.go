@@ -0,0 +1,54 @@
+package voice
+
+import "time"
+
+// Clock é a fonte de tempo do manager e dos players: o ritmo de 20ms dos frames,
+// as esperas da conexão, os prazos e os timers (watchdog dos downloads, saída por
+// inatividade e votações do bot). Os testes usam um relógio falso que só avança
+// quando mandado.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer é o retorno do AfterFunc
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker é um time.Ticker com o canal atrás de um método
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock é o relógio real (pacote time)
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
+
+// sleep espera d no relógio dado
+func sleep(clock Clock, d time.Duration) {
+	<-clock.After(d)
+}
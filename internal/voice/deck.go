@@ -0,0 +1,187 @@
+package voice
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// deck é um item da fila aberto para tocar: fonte com efeitos, posição e ganho.
+// Durante um crossfade existem dois decks abertos ao mesmo tempo.
+type deck struct {
+	item           *QueueItem
+	reader         *effectReader
+	start          int // Frame da fonte em que o reader foi aberto
+	effectsVersion int
+	normalization  float64
+	failed         bool // A fonte falhou durante o playback
+}
+
+// openDeck abre a fonte do item no frame start, normalizada para o alvo (em LUFS)
+func openDeck(item *QueueItem, start int, targetLUFS float64) (*deck, error) {
+	src, err := item.Track.Source.Open(time.Duration(start) * frameDuration)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errSource, err)
+	}
+	return &deck{
+		item:           item,
+		reader:         newEffectReader(src),
+		start:          start,
+		effectsVersion: -1,
+		normalization:  item.Track.NormalizationGain(targetLUFS),
+	}, nil
+}
+
+// pos é o frame atual da fonte. Com nightcore/vaporwave ele anda mais ou menos que um frame por tick.
+func (d *deck) pos() int {
+	return d.start + d.reader.consumed
+}
+
+// remaining retorna quantos frames faltam para o fim da faixa (-1 se a duração é desconhecida)
+func (d *deck) remaining() int {
+	if d.item.Track.Duration <= 0 {
+		return -1
+	}
+	return int(d.item.Track.Duration/frameDuration) - d.pos()
+}
+
+// seek reabre a fonte no frame pedido, mantendo os efeitos. Usado também para voltar ao início do loop.
+func (d *deck) seek(target int) error {
+	src, err := d.item.Track.Source.Open(time.Duration(target) * frameDuration)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errSource, err)
+	}
+	d.reader.replace(src)
+	d.start = target
+	return nil
+}
+
+// read lê o próximo frame com os efeitos ligados no momento
+func (d *deck) read(sess *Session) (Frame, error) {
+	if names, version := sess.effectState(); version != d.effectsVersion {
+		d.reader.setEffects(names)
+		d.effectsVersion = version
+	}
+	return d.reader.ReadFrame()
+}
+
+func (d *deck) Close() error {
+	return d.reader.Close()
+}
+
+// output é o lado do Discord do player: um único ticker e encoder durante toda a
+// execução, para que a troca de loop ou de faixa não perca nenhum frame.
+type output struct {
+	ticker Ticker
+	enc    frameEncoder
+	due    bool // Um tick foi consumido sem enviar frame (fim de faixa): o próximo sai sem esperar
+	fader  fader
+
+	// Crossfade: as duas faixas são mixadas em PCM antes do encoder
+	decoders [2]opusDecoder
+	mix      []float64
+	mixed    []int16
+
+	// Controle de retry de conexão
+	lostConnectionFrames int
+}
+
+func newOutput(clock Clock) *output {
+	return &output{
+		ticker: clock.NewTicker(frameDuration),
+		mix:    make([]float64, frameSize*channels),
+		mixed:  make([]int16, frameSize*channels),
+	}
+}
+
+func (o *output) Close() {
+	o.ticker.Stop()
+}
+
+// connection retorna a conexão pronta para envio, ou nil enquanto ela se recupera.
+// Começa a reconectar depois de Retry.ReconnectAfter e desiste (erro fatal) quando a política de Retry desiste.
+func (o *output) connection(sess *Session) (VoiceTransport, error) {
+	m := sess.owner
+	reconnectFrames := max(int(m.retry.ReconnectAfter/frameDuration), 1)
+
+	// Acessamos via GetConnection (Safe/Locked) para pegar a instância mais atual
+	vc := sess.GetConnection()
+
+	if !transportReady(vc) {
+		o.lostConnectionFrames++
+
+		if o.lostConnectionFrames == 1 {
+			m.log.Warn("Conexão de voz instável/perdida. Aguardando recuperação...", "guild_id", sess.GuildID)
+		}
+
+		// Lógica de autoreconexão
+		// A cada ReconnectAfter sem conexão uma reconexão começa em segundo plano (se
+		// nenhuma estiver rodando); o player segue no ritmo dos frames sem esperar por ela
+		if o.lostConnectionFrames%reconnectFrames == 0 {
+			m.log.Warn("Tentando reconexão automática de voz (Retry)...", "guild_id", sess.GuildID)
+			sess.requestReconnect()
+		}
+
+		// A política desistiu (tentativas esgotadas ou disjuntor aberto): erro fatal
+		if err := sess.reconnectFailure(); err != nil {
+			return nil, fmt.Errorf("sem conexão de voz há %s: %w", time.Duration(o.lostConnectionFrames)*frameDuration, err)
+		}
+		return nil, nil
+	}
+
+	// Se recuperou de uma falha
+	if o.lostConnectionFrames > 0 {
+		m.log.Info("Conexão de voz restabelecida!", "guild_id", sess.GuildID, "waited_frames", o.lostConnectionFrames)
+		o.lostConnectionFrames = 0
+	}
+	return vc, nil
+}
+
+// send envia de forma não bloqueante.
+// O canal OpusSend pode bloquear se a conexão UDP cair; usamos select/default
+// para evitar travar a Goroutine.
+func (o *output) send(vc VoiceTransport, opusData []byte) {
+	if opusSend := vc.OpusSend(); vc.Ready() && opusSend != nil {
+		select {
+		case opusSend <- opusData:
+			// Enviado com sucesso
+		default:
+			// Buffer cheio ou bloqueado, dropamos o frame
+		}
+	}
+}
+
+// startCrossfade troca os decoders do crossfade por novos: o estado Opus que eles
+// guardam é o das faixas do crossfade anterior, não o destas
+func (o *output) startCrossfade() {
+	o.decoders = [2]opusDecoder{}
+}
+
+// crossfade mixa o fim de uma faixa (a) com o começo da próxima (b) em potência
+// constante. t vai de 0 (só a) a 1 (só b); cada lado já vem com o próprio ganho.
+func (o *output) crossfade(a Frame, gainA float64, b Frame, gainB float64, t float64) (Frame, error) {
+	fadeOut := math.Cos(t * math.Pi / 2)
+	fadeIn := math.Sin(t * math.Pi / 2)
+
+	clear(o.mix)
+	for n, side := range []struct {
+		frame Frame
+		gain  float64
+	}{{a, gainA * fadeOut}, {b, gainB * fadeIn}} {
+		pcm := side.frame.PCM
+		if pcm == nil {
+			var err error
+			if pcm, err = o.decoders[n].decode(side.frame.Opus); err != nil {
+				return Frame{}, err
+			}
+		}
+		for i, s := range pcm {
+			o.mix[i] += float64(s) / 32768.0 * side.gain
+		}
+	}
+
+	for i, v := range o.mix {
+		o.mixed[i] = toSample(softClip(v))
+	}
+	return Frame{PCM: o.mixed}, nil
+}
@@ -0,0 +1,151 @@
+package voice
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+)
+
+// ErrUnsupportedFormat é retornado quando o decoder não reconhece o formato do áudio
+var ErrUnsupportedFormat = errors.New("formato de áudio não suportado")
+
+// Decoder converte um arquivo de áudio em PCM s16le, 48kHz, estéreo
+type Decoder interface {
+	// Name identifica o decoder no /status e nos logs
+	Name() string
+	// Decode devolve o stream PCM do áudio lido de r. Fechar o stream libera os recursos.
+	Decode(r io.Reader) (io.ReadCloser, error)
+}
+
+// NewDecoder escolhe o decoder pelo nome: "ffmpeg", "native" ou "auto" (vazio = auto)
+func NewDecoder(name string) (Decoder, error) {
+	switch name {
+	case "", "auto":
+		return NewAutoDecoder(), nil
+	case "native":
+		return nativeDecoder{}, nil
+	case "ffmpeg":
+		if _, err := exec.LookPath("ffmpeg"); err != nil {
+			return nil, fmt.Errorf("decoder ffmpeg pedido mas o binário não foi encontrado: %w", err)
+		}
+		return ffmpegDecoder{}, nil
+	}
+	return nil, fmt.Errorf("decoder desconhecido: %q (use auto, native ou ffmpeg)", name)
+}
+
+// ffmpegDecoder delega a decodificação ao binário ffmpeg (qualquer formato que ele suporte)
+type ffmpegDecoder struct{}
+
+func (ffmpegDecoder) Name() string { return "ffmpeg" }
+
+func (ffmpegDecoder) Decode(r io.Reader) (io.ReadCloser, error) {
+	run := exec.Command("ffmpeg", "-i", "pipe:0", "-f", "s16le", "-ar", strconv.Itoa(frameRate), "-ac", strconv.Itoa(channels), "pipe:1")
+	run.Stdin = r
+
+	stderr := &bytes.Buffer{}
+	run.Stderr = stderr
+
+	out, err := run.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := run.Start(); err != nil {
+		return nil, fmt.Errorf("erro ao iniciar ffmpeg: %w", err)
+	}
+	return &ffmpegStream{ReadCloser: out, run: run, stderr: stderr}, nil
+}
+
+// ffmpegStream é a saída do ffmpeg; Close encerra o processo
+type ffmpegStream struct {
+	io.ReadCloser
+	run    *exec.Cmd
+	stderr *bytes.Buffer
+	eof    bool
+}
+
+func (f *ffmpegStream) Read(p []byte) (int, error) {
+	n, err := f.ReadCloser.Read(p)
+	if err == io.EOF {
+		f.eof = true
+	}
+	return n, err
+}
+
+func (f *ffmpegStream) Close() error {
+	// Se o stream não foi lido até o fim, o ffmpeg ainda pode estar rodando
+	if !f.eof {
+		f.run.Process.Kill()
+	}
+	f.ReadCloser.Close()
+
+	if err := f.run.Wait(); err != nil && f.eof {
+		return fmt.Errorf("erro ao decodificar áudio com ffmpeg: %w (%s)", err, bytes.TrimSpace(f.stderr.Bytes()))
+	}
+	return nil
+}
+
+// autoDecoder usa o decoder nativo para os formatos que ele conhece e o ffmpeg
+// (se estiver instalado) para o resto
+type autoDecoder struct {
+	ffmpeg Decoder // nil quando o ffmpeg não está no PATH
+}
+
+// NewAutoDecoder detecta se o ffmpeg está disponível para formatos extras
+func NewAutoDecoder() Decoder {
+	d := autoDecoder{}
+	if _, err := exec.LookPath("ffmpeg"); err == nil {
+		d.ffmpeg = ffmpegDecoder{}
+	}
+	return d
+}
+
+func (d autoDecoder) Name() string {
+	if d.ffmpeg == nil {
+		return "auto (nativo)"
+	}
+	return "auto (nativo + ffmpeg)"
+}
+
+func (d autoDecoder) Decode(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(64)
+
+	if detectFormat(header) != formatUnknown || d.ffmpeg == nil {
+		return nativeDecoder{}.Decode(br)
+	}
+	return d.ffmpeg.Decode(br)
+}
+
+// audioFormat é o formato detectado pelo cabeçalho do arquivo
+type audioFormat int
+
+const (
+	formatUnknown audioFormat = iota
+	formatWAV
+	formatMP3
+	formatOggOpus
+	formatDCA
+)
+
+// detectFormat reconhece o formato pelos primeiros bytes do arquivo (até 64)
+func detectFormat(header []byte) audioFormat {
+	switch {
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		return formatWAV
+	case len(header) >= 4 && string(header[0:4]) == "OggS" && bytes.Contains(header, []byte("OpusHead")):
+		// Só Ogg com Opus: o primeiro pacote do stream é o OpusHead
+		return formatOggOpus
+	case len(header) >= 4 && string(header[0:4]) == "DCA1":
+		return formatDCA
+	case len(header) >= 3 && string(header[0:3]) == "ID3":
+		return formatMP3
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		// Frame sync do MPEG audio
+		return formatMP3
+	}
+	return formatUnknown
+}
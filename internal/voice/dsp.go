@@ -0,0 +1,180 @@
+package voice
+
+import "math"
+
+// effect processa um frame PCM estéreo intercalado, normalizado em -1..1, no lugar.
+// Efeitos com memória (filtros, delays) guardam o histórico entre frames.
+type effect interface {
+	process(buf []float64)
+}
+
+// biquad é um filtro IIR de segunda ordem (RBJ Audio EQ Cookbook), um estado por canal
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     [channels]float64
+}
+
+// newShelf cria um filtro shelving: low realça/atenua abaixo de freq, high acima
+func newShelf(low bool, freq, gainDB float64) *biquad {
+	a := math.Pow(10, gainDB/40)
+	w0 := 2 * math.Pi * freq / frameRate
+	cos := math.Cos(w0)
+	alpha := math.Sin(w0) / 2 * math.Sqrt2
+	sq := 2 * math.Sqrt(a) * alpha
+
+	var b0, b1, b2, a0, a1, a2 float64
+	if low {
+		b0 = a * ((a + 1) - (a-1)*cos + sq)
+		b1 = 2 * a * ((a - 1) - (a+1)*cos)
+		b2 = a * ((a + 1) - (a-1)*cos - sq)
+		a0 = (a + 1) + (a-1)*cos + sq
+		a1 = -2 * ((a - 1) + (a+1)*cos)
+		a2 = (a + 1) + (a-1)*cos - sq
+	} else {
+		b0 = a * ((a + 1) + (a-1)*cos + sq)
+		b1 = -2 * a * ((a - 1) + (a+1)*cos)
+		b2 = a * ((a + 1) + (a-1)*cos - sq)
+		a0 = (a + 1) - (a-1)*cos + sq
+		a1 = 2 * ((a - 1) - (a+1)*cos)
+		a2 = (a + 1) - (a-1)*cos - sq
+	}
+	return &biquad{b0: b0 / a0, b1: b1 / a0, b2: b2 / a0, a1: a1 / a0, a2: a2 / a0}
+}
+
+func (f *biquad) process(buf []float64) {
+	for i, x := range buf {
+		c := i % channels
+		y := f.b0*x + f.b1*f.x1[c] + f.b2*f.x2[c] - f.a1*f.y1[c] - f.a2*f.y2[c]
+		f.x2[c], f.x1[c] = f.x1[c], x
+		f.y2[c], f.y1[c] = f.y1[c], y
+		buf[i] = y
+	}
+}
+
+// chain aplica vários efeitos em sequência
+type chain []effect
+
+func (c chain) process(buf []float64) {
+	for _, e := range c {
+		e.process(buf)
+	}
+}
+
+// panner gira o som em volta do ouvinte (efeito "8D") com um LFO lento
+type panner struct {
+	period float64 // Segundos por volta
+	t      int     // Amostras (por canal) processadas
+}
+
+func (p *panner) process(buf []float64) {
+	for i := 0; i+1 < len(buf); i += channels {
+		lfo := math.Sin(2 * math.Pi * float64(p.t) / (p.period * frameRate))
+		angle := (lfo + 1) * math.Pi / 4 // 0 = esquerda, π/2 = direita
+		mono := (buf[i] + buf[i+1]) / 2
+		buf[i] = mono * math.Cos(angle) * math.Sqrt2
+		buf[i+1] = mono * math.Sin(angle) * math.Sqrt2
+		p.t++
+	}
+}
+
+// echo repete o som depois de um atraso fixo, com realimentação
+type echo struct {
+	line     []float64 // Delay intercalado (delay * canais)
+	pos      int
+	feedback float64
+	mix      float64
+}
+
+func newEcho(delaySeconds, feedback, mix float64) *echo {
+	return &echo{
+		line:     make([]float64, int(delaySeconds*frameRate)*channels),
+		feedback: feedback,
+		mix:      mix,
+	}
+}
+
+func (e *echo) process(buf []float64) {
+	for i, x := range buf {
+		d := e.line[e.pos]
+		e.line[e.pos] = x + d*e.feedback
+		e.pos = (e.pos + 1) % len(e.line)
+		buf[i] = x + d*e.mix
+	}
+}
+
+// reverb é um reverb de Schroeder: filtros comb em paralelo seguidos de all-pass em série
+type reverb struct {
+	combs    [channels][]*comb
+	allpass  [channels][]*allpass
+	wet, dry float64
+}
+
+// Atrasos do Freeverb, em amostras a 44.1kHz; newReverb os converte para 48kHz.
+// O canal direito é deslocado de reverbStereoSpread.
+var (
+	reverbCombDelays    = []int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	reverbAllpassDelays = []int{556, 441, 341, 225}
+)
+
+const reverbStereoSpread = 23
+
+// freeverbSamples converte um atraso do Freeverb (44.1kHz) para a taxa do player
+func freeverbSamples(n int) int {
+	return int(math.Round(float64(n) * frameRate / 44100))
+}
+
+func newReverb(wet float64) *reverb {
+	r := &reverb{wet: wet, dry: 1}
+	for c := 0; c < channels; c++ {
+		for _, d := range reverbCombDelays {
+			r.combs[c] = append(r.combs[c], &comb{line: make([]float64, freeverbSamples(d+c*reverbStereoSpread)), feedback: 0.84, damp: 0.2})
+		}
+		for _, d := range reverbAllpassDelays {
+			r.allpass[c] = append(r.allpass[c], &allpass{line: make([]float64, freeverbSamples(d+c*reverbStereoSpread))})
+		}
+	}
+	return r
+}
+
+func (r *reverb) process(buf []float64) {
+	for i, x := range buf {
+		c := i % channels
+		var out float64
+		for _, f := range r.combs[c] {
+			out += f.process(x)
+		}
+		out /= float64(len(r.combs[c]))
+		for _, f := range r.allpass[c] {
+			out = f.process(out)
+		}
+		buf[i] = x*r.dry + out*r.wet
+	}
+}
+
+type comb struct {
+	line           []float64
+	pos            int
+	feedback, damp float64
+	store          float64
+}
+
+func (f *comb) process(x float64) float64 {
+	y := f.line[f.pos]
+	f.store = y*(1-f.damp) + f.store*f.damp
+	f.line[f.pos] = x + f.store*f.feedback
+	f.pos = (f.pos + 1) % len(f.line)
+	return y
+}
+
+type allpass struct {
+	line []float64
+	pos  int
+}
+
+func (f *allpass) process(x float64) float64 {
+	d := f.line[f.pos]
+	y := d - 0.5*x
+	f.line[f.pos] = x + 0.5*d
+	f.pos = (f.pos + 1) % len(f.line)
+	return y
+}
@@ -0,0 +1,297 @@
+package voice
+
+import (
+	"errors"
+	"io"
+	"slices"
+)
+
+// ErrUnknownEffect é retornado para nomes fora da lista de efeitos
+var ErrUnknownEffect = errors.New("efeito desconhecido")
+
+// effectDef descreve um efeito disponível no /efeito
+type effectDef struct {
+	name        string
+	description string
+	speed       float64       // != 0 muda velocidade e tom juntos, como acelerar a fita
+	build       func() effect // nil para efeitos só de velocidade
+}
+
+// effectDefs na ordem em que são aplicados
+var effectDefs = []effectDef{
+	{name: "nightcore", description: "Mais rápido e mais agudo (1.25x)", speed: 1.25},
+	{name: "vaporwave", description: "Mais lento e mais grave (0.8x)", speed: 0.8},
+	{name: "bassboost", description: "Graves reforçados", build: func() effect { return newShelf(true, 110, 9) }},
+	{name: "equalizador", description: "Graves e agudos realçados (curva em V)", build: func() effect {
+		return chain{newShelf(true, 120, 4), newShelf(false, 6000, 5)}
+	}},
+	{name: "8d", description: "Som girando em volta da cabeça (use fones)", build: func() effect { return &panner{period: 8} }},
+	{name: "eco", description: "Repetições a cada 250ms", build: func() effect { return newEcho(0.25, 0.35, 0.5) }},
+	{name: "reverb", description: "Ambiente de salão", build: func() effect { return newReverb(0.35) }},
+}
+
+func findEffect(name string) (effectDef, bool) {
+	for _, def := range effectDefs {
+		if def.name == name {
+			return def, true
+		}
+	}
+	return effectDef{}, false
+}
+
+// EffectInfo é o nome e a descrição de um efeito, para montar o comando
+type EffectInfo struct {
+	Name        string
+	Description string
+}
+
+// AvailableEffects lista os efeitos na ordem em que são aplicados
+func AvailableEffects() []EffectInfo {
+	infos := make([]EffectInfo, len(effectDefs))
+	for i, def := range effectDefs {
+		infos[i] = EffectInfo{Name: def.name, Description: def.description}
+	}
+	return infos
+}
+
+// ToggleEffect liga ou desliga o efeito na sessão, valendo para o áudio ao vivo.
+// Efeitos de velocidade (nightcore, vaporwave) se substituem. Retorna true se ficou ligado.
+func (sess *Session) ToggleEffect(name string) (bool, error) {
+	def, ok := findEffect(name)
+	if !ok {
+		return false, ErrUnknownEffect
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if slices.Contains(sess.effects, name) {
+		sess.setEffectsLocked(slices.DeleteFunc(slices.Clone(sess.effects), func(n string) bool { return n == name }))
+		return false, nil
+	}
+
+	effects := append(slices.Clone(sess.effects), name)
+	if def.speed != 0 {
+		effects = slices.DeleteFunc(effects, func(n string) bool {
+			other, _ := findEffect(n)
+			return n != name && other.speed != 0
+		})
+	}
+	sess.setEffectsLocked(effects)
+	return true, nil
+}
+
+// SetEffects substitui todos os efeitos da sessão
+func (sess *Session) SetEffects(names []string) error {
+	for _, name := range names {
+		if _, ok := findEffect(name); !ok {
+			return ErrUnknownEffect
+		}
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.setEffectsLocked(slices.Clone(names))
+	return nil
+}
+
+// ClearEffects desliga todos os efeitos
+func (sess *Session) ClearEffects() {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.setEffectsLocked(nil)
+}
+
+// Effects retorna os efeitos ligados, na ordem em que são aplicados
+func (sess *Session) Effects() []string {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return slices.Clone(sess.effects)
+}
+
+// setEffectsLocked ordena os efeitos pela ordem de aplicação e avisa o player da mudança
+func (sess *Session) setEffectsLocked(names []string) {
+	slices.SortFunc(names, func(a, b string) int {
+		return slices.IndexFunc(effectDefs, func(d effectDef) bool { return d.name == a }) -
+			slices.IndexFunc(effectDefs, func(d effectDef) bool { return d.name == b })
+	})
+	sess.effects = slices.Compact(names)
+	sess.effectsVersion++
+}
+
+// effectState retorna os efeitos e a versão, para o player só reconstruir a cadeia quando mudar
+func (sess *Session) effectState() ([]string, int) {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.effects, sess.effectsVersion
+}
+
+// effectReader aplica a cadeia de efeitos sobre os frames da fonte.
+// Sem efeitos os frames passam intactos (mantendo o passthrough Opus). Efeitos de
+// velocidade reamostram a fonte, então um frame de saída pode consumir mais ou
+// menos de um frame da fonte; consumed conta os frames da fonte lidos.
+type effectReader struct {
+	src      FrameReader
+	decoder  opusDecoder
+	chain    chain
+	speed    float64   // 1 = velocidade normal
+	pending  []float64 // Amostras da fonte aguardando o resampler (intercaladas)
+	phase    float64   // Posição fracionária dentro de pending (amostras por canal)
+	eof      bool
+	consumed int
+
+	buf []float64
+	out []int16
+}
+
+func newEffectReader(src FrameReader) *effectReader {
+	return &effectReader{
+		src:   src,
+		speed: 1,
+		buf:   make([]float64, frameSize*channels),
+		out:   make([]int16, frameSize*channels),
+	}
+}
+
+// setEffects reconstrói a cadeia (o histórico dos filtros recomeça do zero)
+func (r *effectReader) setEffects(names []string) {
+	// Sem efeitos os pacotes passaram direto, sem passar pelo decoder: o estado dele é de antes
+	if r.passthrough() {
+		r.decoder = opusDecoder{}
+	}
+	r.chain = nil
+	speed := 1.0
+	for _, name := range names {
+		def, _ := findEffect(name)
+		if def.speed != 0 {
+			speed *= def.speed
+		}
+		if def.build != nil {
+			r.chain = append(r.chain, def.build())
+		}
+	}
+
+	if speed == 1 {
+		r.pending = r.pending[:0]
+		r.phase = 0
+	}
+	r.speed = speed
+}
+
+// replace troca a fonte (usado pelo /seek e na volta do loop), mantendo a cadeia de
+// efeitos. O decoder recomeça: os pacotes da fonte nova não continuam os anteriores.
+func (r *effectReader) replace(src FrameReader) {
+	r.src.Close()
+	r.src = src
+	r.decoder = opusDecoder{}
+	r.pending = r.pending[:0]
+	r.phase = 0
+	r.eof = false
+	r.consumed = 0
+}
+
+// passthrough indica se os frames passam intactos (nenhum efeito ligado)
+func (r *effectReader) passthrough() bool {
+	return len(r.chain) == 0 && r.speed == 1
+}
+
+func (r *effectReader) ReadFrame() (Frame, error) {
+	if r.passthrough() {
+		f, err := r.src.ReadFrame()
+		if err == nil {
+			r.consumed++
+		}
+		return f, err
+	}
+
+	if r.speed == 1 {
+		f, err := r.src.ReadFrame()
+		if err != nil {
+			return Frame{}, err
+		}
+		r.consumed++
+		pcm, err := r.pcm(f)
+		if err != nil {
+			return Frame{}, err
+		}
+		for i, s := range pcm {
+			r.buf[i] = float64(s) / 32768.0
+		}
+	} else if err := r.resample(); err != nil {
+		return Frame{}, err
+	}
+
+	r.chain.process(r.buf)
+	for i, v := range r.buf {
+		r.out[i] = toSample(softClip(v))
+	}
+	return Frame{PCM: r.out}, nil
+}
+
+// resample preenche buf lendo a fonte na velocidade atual (interpolação linear).
+// O último frame é completado com silêncio.
+func (r *effectReader) resample() error {
+	for i := 0; i < frameSize; i++ {
+		idx := int(r.phase)
+		for (idx+1)*channels >= len(r.pending) && !r.eof {
+			if err := r.fill(); err != nil {
+				return err
+			}
+		}
+		if (idx+1)*channels >= len(r.pending) {
+			if i == 0 {
+				return io.EOF
+			}
+			clear(r.buf[i*channels:])
+			break
+		}
+
+		frac := r.phase - float64(idx)
+		for c := 0; c < channels; c++ {
+			a := r.pending[idx*channels+c]
+			b := r.pending[(idx+1)*channels+c]
+			r.buf[i*channels+c] = a + (b-a)*frac
+		}
+		r.phase += r.speed
+	}
+
+	// Descarta as amostras já usadas
+	drop := min(int(r.phase), len(r.pending)/channels)
+	r.pending = append(r.pending[:0], r.pending[drop*channels:]...)
+	r.phase -= float64(drop)
+	return nil
+}
+
+// fill lê o próximo frame da fonte para pending
+func (r *effectReader) fill() error {
+	f, err := r.src.ReadFrame()
+	if err == io.EOF {
+		r.eof = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.consumed++
+
+	pcm, err := r.pcm(f)
+	if err != nil {
+		return err
+	}
+	for _, s := range pcm {
+		r.pending = append(r.pending, float64(s)/32768.0)
+	}
+	return nil
+}
+
+// pcm retorna as amostras do frame, decodificando o Opus quando a fonte não tem PCM
+func (r *effectReader) pcm(f Frame) ([]int16, error) {
+	if f.PCM != nil {
+		return f.PCM, nil
+	}
+	return r.decoder.decode(f.Opus)
+}
+
+func (r *effectReader) Close() error {
+	return r.src.Close()
+}
@@ -0,0 +1,175 @@
+package voice
+
+import (
+	"io"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// readAll lê o effectReader até o EOF, copiando os frames PCM
+func readAll(t *testing.T, r *effectReader) [][]int16 {
+	t.Helper()
+	var frames [][]int16
+	for {
+		f, err := r.ReadFrame()
+		if err == io.EOF {
+			return frames
+		}
+		if err != nil {
+			t.Fatalf("frame %d: %v", len(frames), err)
+		}
+		frames = append(frames, slices.Clone(f.PCM))
+	}
+}
+
+// level é o RMS dos frames (0..1)
+func level(frames [][]int16) float64 {
+	var sum float64
+	var n int
+	for _, f := range frames {
+		for _, s := range f {
+			v := float64(s) / 32768
+			sum += v * v
+		}
+		n += len(f)
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+func effectTone(t *testing.T, frequency, amplitude float64, effects ...string) [][]int16 {
+	t.Helper()
+	src, _ := (&ToneSource{Frequency: frequency, Amplitude: amplitude, Length: time.Second}).Open(0)
+	r := newEffectReader(src)
+	r.setEffects(effects)
+	return readAll(t, r)
+}
+
+// Sem efeitos os frames saem iguais aos da fonte, inclusive os pacotes Opus
+func TestEffectReaderIdentity(t *testing.T) {
+	tone, _ := NewToneSource(440, time.Second).Open(0)
+	want := readAll(t, newEffectReader(tone))
+
+	src, _ := NewToneSource(440, time.Second).Open(0)
+	r := newEffectReader(src)
+	r.setEffects(nil)
+	got := readAll(t, r)
+	if len(got) != 50 || r.consumed != 50 {
+		t.Fatalf("%d frames, %d consumidos, esperado 50", len(got), r.consumed)
+	}
+	for n := range got {
+		if !slices.Equal(got[n], want[n]) {
+			t.Fatalf("frame %d alterado sem efeitos", n)
+		}
+	}
+
+	item := passthroughItem(t, "a", 440, 0.5, 200*time.Millisecond)
+	packets, _ := item.Track.Source.Open(0)
+	r = newEffectReader(packets)
+	for n, packet := range item.Track.Source.(*Audio).Frames {
+		f, err := r.ReadFrame()
+		if err != nil || f.PCM != nil || string(f.Opus) != string(packet) {
+			t.Fatalf("pacote %d não passou direto (err %v)", n, err)
+		}
+	}
+}
+
+// Efeitos de velocidade mudam a duração: 1s de fonte vira 0.8s no nightcore e 1.25s no vaporwave
+func TestEffectSpeedLength(t *testing.T) {
+	tests := []struct {
+		effect string
+		frames int
+	}{
+		{"nightcore", 40},
+		{"vaporwave", 63},
+	}
+	for _, tt := range tests {
+		if got := len(effectTone(t, 440, 0.5, tt.effect)); got != tt.frames {
+			t.Errorf("%s: %d frames, esperado %d", tt.effect, got, tt.frames)
+		}
+	}
+}
+
+// O bassboost reforça um tom grave e quase não mexe num agudo
+func TestEffectBassboostGain(t *testing.T) {
+	tests := []struct {
+		frequency    float64
+		minDB, maxDB float64
+	}{
+		{50, 6, 10},
+		{5000, -1, 1},
+	}
+	for _, tt := range tests {
+		// Ignora o começo, enquanto o filtro assenta
+		dry := level(effectTone(t, tt.frequency, 0.1)[10:])
+		wet := level(effectTone(t, tt.frequency, 0.1, "bassboost")[10:])
+		if gain := 20 * math.Log10(wet/dry); gain < tt.minDB || gain > tt.maxDB {
+			t.Errorf("%.0fHz: ganho %.1fdB, esperado entre %.0f e %.0fdB", tt.frequency, gain, tt.minDB, tt.maxDB)
+		}
+	}
+}
+
+// Depois de um impulso a cauda do reverb aparece e vai morrendo
+func TestEffectReverbTailDecays(t *testing.T) {
+	r := newReverb(0.35)
+	const window = 10 // Frames (200ms)
+	var energy []float64
+	buf := make([]float64, frameSize*channels)
+	for n := 0; n < 6*window; n++ {
+		clear(buf)
+		if n == 0 {
+			buf[0], buf[1] = 1, 1
+		}
+		r.process(buf)
+		if n == 0 {
+			buf[0], buf[1] = 0, 0 // Só a cauda, sem o sinal seco
+		}
+		if n%window == 0 {
+			energy = append(energy, 0)
+		}
+		for _, v := range buf {
+			energy[len(energy)-1] += v * v
+		}
+	}
+
+	if energy[0] == 0 {
+		t.Fatal("o impulso não gerou cauda")
+	}
+	for i := 1; i < len(energy); i++ {
+		if energy[i] >= energy[i-1] {
+			t.Errorf("energia da janela %d (%g) não caiu em relação à anterior (%g)", i, energy[i], energy[i-1])
+		}
+	}
+	if last := energy[len(energy)-1]; last > energy[0]/100 {
+		t.Errorf("cauda ainda em %g depois de 1s, começou em %g", last, energy[0])
+	}
+}
+
+// Num /seek o decoder recomeça: o primeiro frame da fonte nova sai igual ao de um reader novo
+func TestEffectReaderReplaceResetsDecoder(t *testing.T) {
+	audio := passthroughItem(t, "a", 440, 0.5, time.Second).Track.Source.(*Audio)
+	open := func(offset time.Duration) FrameReader {
+		r, err := audio.Open(offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	r := newEffectReader(open(0))
+	r.setEffects([]string{"bassboost"})
+	for range 20 {
+		r.ReadFrame()
+	}
+	r.replace(open(500 * time.Millisecond))
+	r.setEffects([]string{"bassboost"}) // Zera o filtro, para comparar só o decoder
+	got, _ := r.ReadFrame()
+
+	fresh := newEffectReader(open(500 * time.Millisecond))
+	fresh.setEffects([]string{"bassboost"})
+	want, _ := fresh.ReadFrame()
+	if !slices.Equal(got.PCM, want.PCM) {
+		t.Error("o decoder continuou com o estado da posição antiga depois do replace")
+	}
+}
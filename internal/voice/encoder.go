@@ -0,0 +1,83 @@
+package voice
+
+import (
+	"fmt"
+
+	"layeh.com/gopus"
+)
+
+// frameEncoder transforma os Frames da fonte em pacotes Opus para o Discord.
+// Pacotes prontos sem ganho (volume 100% e faixa já no alvo de loudness) passam
+// direto; nos demais casos o ganho é aplicado sobre o PCM (decodificando o Opus
+// quando a fonte não tem PCM).
+// Encoder e decoder são criados sob demanda e vivem enquanto o player roda. O Opus
+// guarda estado entre pacotes, então os dois são recriados quando a sequência que
+// conhecem é interrompida: depois de frames que passaram direto (o decoder não os viu
+// e o encoder parou no meio) e, só o decoder, na troca de faixa e no /seek.
+type frameEncoder struct {
+	encoder *gopus.Encoder
+	decoder opusDecoder
+	buf     []int16
+	passed  bool // Frames passaram direto desde o último encode
+}
+
+// encode codifica o frame com o ganho indo de from a to ao longo dele (veja applyGain)
+func (e *frameEncoder) encode(f Frame, from, to float64) ([]byte, error) {
+	if f.Opus != nil && from == 1 && to == 1 {
+		e.passed = true
+		return f.Opus, nil
+	}
+	if e.passed {
+		e.encoder, e.decoder, e.passed = nil, opusDecoder{}, false
+	}
+
+	pcm := f.PCM
+	if pcm == nil {
+		var err error
+		if pcm, err = e.decoder.decode(f.Opus); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.encoder == nil {
+		var err error
+		e.encoder, err = gopus.NewEncoder(frameRate, channels, gopus.Audio)
+		if err != nil {
+			return nil, fmt.Errorf("falha encoder: %v", err)
+		}
+		e.buf = make([]int16, frameSize*channels)
+	}
+
+	applyGain(e.buf, pcm, from, to)
+	return e.encoder.Encode(e.buf, frameSize, maxBytes)
+}
+
+// sourceChanged descarta o decoder quando os próximos pacotes não continuam os
+// anteriores (outra faixa ou outro ponto dela). O encoder fica: o stream que ele
+// gera para o Discord continua o mesmo.
+func (e *frameEncoder) sourceChanged() {
+	e.decoder = opusDecoder{}
+}
+
+// opusDecoder decodifica os pacotes de fontes sem PCM (passthrough), criado sob demanda
+type opusDecoder struct {
+	decoder *gopus.Decoder
+}
+
+// decode converte um pacote Opus em exatamente um frame de PCM
+func (d *opusDecoder) decode(packet []byte) ([]int16, error) {
+	if d.decoder == nil {
+		var err error
+		d.decoder, err = gopus.NewDecoder(frameRate, channels)
+		if err != nil {
+			return nil, fmt.Errorf("falha decoder opus: %v", err)
+		}
+	}
+
+	samples, err := d.decoder.Decode(packet, frameSize, false)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar frame: %v", err)
+	}
+	size := frameSize * channels
+	return append(samples, make([]int16, max(0, size-len(samples)))...)[:size], nil
+}
@@ -0,0 +1,45 @@
+package voice
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// Depois de frames que passaram direto, o encode parte de encoder e decoder novos:
+// o pacote sai igual ao de um frameEncoder recém-criado
+func TestFrameEncoderResetsAfterPassthrough(t *testing.T) {
+	item := passthroughItem(t, "a", 440, 0.5, 600*time.Millisecond)
+	frames := item.Track.Source.(*Audio).Frames
+
+	var enc frameEncoder
+	for n, packet := range frames[:len(frames)-1] {
+		gain := 0.5
+		if n >= 10 {
+			gain = 1 // Volume volta a 100%: os pacotes passam direto
+		}
+		if _, err := enc.encode(Frame{Opus: packet}, gain, gain); err != nil {
+			t.Fatalf("frame %d: %v", n, err)
+		}
+	}
+
+	last := Frame{Opus: frames[len(frames)-1]}
+	got, err := enc.encode(last, 0.5, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fresh frameEncoder
+	want, _ := fresh.encode(last, 0.5, 0.5)
+	if !bytes.Equal(got, want) {
+		t.Error("encode depois do passthrough reaproveitou o estado Opus de antes dele")
+	}
+
+	// Sem passthrough no meio o estado continua (o pacote depende dos anteriores)
+	var steady frameEncoder
+	for _, packet := range frames[:len(frames)-1] {
+		steady.encode(Frame{Opus: packet}, 0.5, 0.5)
+	}
+	if cont, _ := steady.encode(last, 0.5, 0.5); bytes.Equal(cont, want) {
+		t.Error("encode contínuo saiu igual ao de um encoder novo: o teste não distingue os casos")
+	}
+}
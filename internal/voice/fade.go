@@ -0,0 +1,119 @@
+package voice
+
+import "time"
+
+// DefaultFade é a duração padrão das rampas de volume ao iniciar, pausar, pular e parar
+const DefaultFade = 250 * time.Millisecond
+
+// Folga além do fade para o player perceber o cancelamento (até um tick) e encerrar
+const fadeGrace = 200 * time.Millisecond
+
+// fader é a rampa de ganho por cima do volume: 0 = silêncio, 1 = volume cheio.
+// Começa em silêncio, então o primeiro frame do player já entra com fade-in.
+type fader struct {
+	gain float64
+}
+
+// next avança a rampa um frame em direção a target e retorna o ganho no começo e
+// no fim do frame, para que a rampa seja aplicada amostra a amostra. Com fade 0 o
+// ganho vai direto ao alvo, sem rampa nem dentro do frame (inclusive no primeiro).
+func (f *fader) next(target float64, fade time.Duration) (from, to float64) {
+	if fade <= 0 {
+		f.gain = target
+		return target, target
+	}
+
+	from = f.gain
+	step := float64(frameDuration) / float64(fade)
+	if target > f.gain {
+		f.gain = min(f.gain+step, target)
+	} else {
+		f.gain = max(f.gain-step, target)
+	}
+	return from, f.gain
+}
+
+func (f *fader) silent() bool {
+	return f.gain == 0
+}
+
+// cut zera a rampa sem fade (conexão perdida): o áudio volta com fade-in
+func (f *fader) cut() {
+	f.gain = 0
+}
+
+// fadeOut toca o resto do deck até a rampa chegar ao silêncio, para que parar ou
+// pular não corte o som no meio de um frame. Efeitos e crossfade ficam de fora:
+// são poucos frames.
+func (o *output) fadeOut(sess *Session, d *deck) {
+	fade := sess.Fade()
+	if fade <= 0 {
+		o.fader.cut()
+		return
+	}
+
+	for !o.fader.silent() {
+		<-o.ticker.C()
+
+		vc := sess.GetConnection()
+		if !transportReady(vc) {
+			o.fader.cut()
+			return
+		}
+		frame, err := d.read(sess)
+		if err != nil {
+			o.fader.cut()
+			return
+		}
+
+		deadline, _ := sess.deadlineFade()
+		gain := d.normalization * float64(sess.Volume()) / 100 * deadline
+		from, to := o.fader.next(0, fade)
+		opusData, err := o.enc.encode(frame, gain*from, gain*to)
+		if err != nil {
+			continue
+		}
+		o.send(vc, opusData)
+	}
+}
+
+// SetFade define a duração das rampas de volume (0 = corte seco)
+func (sess *Session) SetFade(d time.Duration) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.fade = max(d, 0)
+}
+
+func (sess *Session) Fade() time.Duration {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.fade
+}
+
+// stopAndWait cancela o player e espera o fade-out dele terminar (no máximo a
+// duração do fade mais uma folga)
+func (sess *Session) stopAndWait() {
+	sess.mu.Lock()
+	if sess.cancel != nil {
+		sess.cancel()
+	}
+	done, fade := sess.done, sess.fade
+	sess.mu.Unlock()
+
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-sess.owner.clock.After(fade + fadeGrace):
+	}
+}
+
+// LeaveAfterFade sai do canal como o Leave, mas antes espera o fade-out do que
+// estiver tocando, para não cortar o áudio no meio de um frame
+func (m *Manager) LeaveAfterFade(guildID string) {
+	if sess := m.GetSession(guildID); sess != nil {
+		sess.stopAndWait()
+	}
+	m.Leave(guildID)
+}
@@ -0,0 +1,138 @@
+package voice
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock é um Clock que só anda no Advance. Os canais do After e dos tickers têm
+// buffer 1 e descartam disparos com o buffer cheio, como os do pacote time.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter é um After, AfterFunc ou ticker esperando o relógio chegar em at
+type fakeWaiter struct {
+	clock  *fakeClock
+	at     time.Time
+	ch     chan time.Time
+	f      func()
+	period time.Duration // > 0 = ticker
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	w := &fakeWaiter{clock: c, ch: make(chan time.Time, 1)}
+	c.add(w, d)
+	return w.ch
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	w := &fakeWaiter{clock: c, f: f}
+	c.add(w, d)
+	return w
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	w := &fakeWaiter{clock: c, ch: make(chan time.Time, 1), period: d}
+	c.add(w, d)
+	return fakeTicker{w}
+}
+
+func (c *fakeClock) add(w *fakeWaiter, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w.at = c.now.Add(d)
+	c.waiters = append(c.waiters, w)
+}
+
+// remove tira w da lista e retorna se ele ainda estava esperando
+func (c *fakeClock) remove(w *fakeWaiter) bool {
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance anda d no relógio, disparando em ordem o que vencer no caminho. Os
+// AfterFunc rodam em goroutines próprias, como no time.AfterFunc.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for {
+		sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+		if len(c.waiters) == 0 || c.waiters[0].at.After(end) {
+			break
+		}
+		w := c.waiters[0]
+		c.now = w.at
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			c.waiters = c.waiters[1:]
+		}
+		if w.f != nil {
+			go w.f()
+			continue
+		}
+		select {
+		case w.ch <- c.now:
+		default:
+		}
+	}
+	c.now = end
+	c.mu.Unlock()
+}
+
+// advanceUntil anda o relógio de frame em frame, dando tempo às goroutines entre os
+// passos, até cond passar. O limite é em tempo real, não no relógio falso.
+func (c *fakeClock) advanceUntil(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout avançando o relógio: %s", msg)
+		}
+		c.Advance(frameDuration)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.remove(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	active := w.clock.remove(w)
+	w.at = w.clock.now.Add(d)
+	w.clock.waiters = append(w.clock.waiters, w)
+	return active
+}
+
+// fakeTicker adapta o fakeWaiter ao Ticker (Stop sem retorno)
+type fakeTicker struct {
+	w *fakeWaiter
+}
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+func (t fakeTicker) Stop()               { t.w.Stop() }
@@ -0,0 +1,280 @@
+package voice
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeState simula o estado do UDP por trás do canal OpusSend
+type fakeState int
+
+const (
+	fakeOK       fakeState = iota // Pacotes chegam e são gravados
+	fakeDropping                  // Pacotes são lidos e descartados (perda de rede)
+	fakeBlocked                   // Ninguém lê o canal: ele enche e o envio trava
+)
+
+// sentFrame é um pacote Opus que chegou à conexão falsa
+type sentFrame struct {
+	at   time.Time
+	opus []byte
+}
+
+// fakeTransport é uma VoiceTransport em memória. Um consumidor lê OpusSend como o
+// opusSender do discordgo (canal com buffer 2) e grava cada pacote com o horário.
+type fakeTransport struct {
+	send chan []byte
+	done chan struct{}
+
+	mu           sync.Mutex
+	ready        bool
+	state        fakeState
+	frames       []sentFrame
+	dropped      int
+	speaking     []bool
+	channelID    string
+	disconnected bool
+
+	migrations   int
+	migrateDelay time.Duration // Tempo até a conexão migrada ficar pronta (< 0 = nunca, 0 = na hora)
+	generation   uint64        // Incrementada a cada conexão migrada pronta
+}
+
+func newFakeTransport(channelID string) *fakeTransport {
+	f := &fakeTransport{
+		send:      make(chan []byte, 2),
+		done:      make(chan struct{}),
+		ready:     true,
+		channelID: channelID,
+	}
+	go f.consume()
+	return f
+}
+
+func (f *fakeTransport) consume() {
+	for {
+		f.mu.Lock()
+		state := f.state
+		f.mu.Unlock()
+
+		if state == fakeBlocked {
+			select {
+			case <-f.done:
+				return
+			case <-time.After(time.Millisecond):
+			}
+			continue
+		}
+
+		select {
+		case <-f.done:
+			return
+		case pkt := <-f.send:
+			f.mu.Lock()
+			if f.state == fakeDropping {
+				f.dropped++
+			} else {
+				f.frames = append(f.frames, sentFrame{at: time.Now(), opus: pkt})
+			}
+			f.mu.Unlock()
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (f *fakeTransport) Ready() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ready
+}
+
+func (f *fakeTransport) Generation() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.generation
+}
+
+func (f *fakeTransport) OpusSend() chan<- []byte {
+	return f.send
+}
+
+func (f *fakeTransport) Speaking(speaking bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.speaking = append(f.speaking, speaking)
+	return nil
+}
+
+func (f *fakeTransport) ChangeChannel(channelID string, mute, deaf bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channelID = channelID
+	return nil
+}
+
+// serverUpdate faz o que o onVoiceServerUpdate do discordgo faz com a conexão:
+// derruba o Ready e só volta depois do handshake no servidor novo (migrateDelay).
+// Com migrateDelay 0 a conexão volta antes de serverUpdate retornar, sem ninguém
+// ter visto o Ready cair.
+func (f *fakeTransport) serverUpdate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ready = false
+	f.migrations++
+	if f.migrateDelay < 0 {
+		return
+	}
+	if f.migrateDelay == 0 {
+		f.reopenLocked()
+		return
+	}
+
+	seq := f.migrations
+	time.AfterFunc(f.migrateDelay, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		// Só a migração mais recente volta, e nunca depois do Disconnect
+		if f.migrations == seq {
+			f.reopenLocked()
+		}
+	})
+}
+
+// reopenLocked deixa pronta a conexão no servidor novo, se não houve Disconnect
+func (f *fakeTransport) reopenLocked() {
+	if !f.disconnected {
+		f.ready = true
+		f.generation++
+	}
+}
+
+// setMigrate configura a espera das próximas migrações até a conexão ficar pronta
+func (f *fakeTransport) setMigrate(delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.migrateDelay = delay
+}
+
+func (f *fakeTransport) Disconnect() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.disconnected {
+		return fmt.Errorf("já desconectado")
+	}
+	f.ready = false
+	f.disconnected = true
+	close(f.done)
+	return nil
+}
+
+func (f *fakeTransport) setReady(ready bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ready = ready
+}
+
+func (f *fakeTransport) setState(state fakeState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state = state
+}
+
+func (f *fakeTransport) isDisconnected() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.disconnected
+}
+
+// audio retorna os pacotes recebidos, sem os frames de silêncio do pre-roll
+func (f *fakeTransport) audio() []sentFrame {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var frames []sentFrame
+	for _, fr := range f.frames {
+		if len(fr.opus) > 3 {
+			frames = append(frames, fr)
+		}
+	}
+	return frames
+}
+
+func (f *fakeTransport) droppedFrames() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dropped
+}
+
+// fakeDialer entrega as conexões na ordem em que foram criadas e conta as chamadas
+type fakeDialer struct {
+	mu         sync.Mutex
+	transports []*fakeTransport
+	calls      int
+	failing    bool          // Chamadas falham (servidor de voz fora do ar)
+	hold       chan struct{} // Se não for nil, as chamadas esperam ele fechar
+}
+
+func (d *fakeDialer) dial(guildID, channelID string) (VoiceTransport, error) {
+	d.mu.Lock()
+	hold := d.hold
+	d.mu.Unlock()
+	if hold != nil {
+		<-hold
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.calls++
+	if d.failing {
+		return nil, fmt.Errorf("servidor de voz indisponível")
+	}
+	t := newFakeTransport(channelID)
+	d.transports = append(d.transports, t)
+	return t, nil
+}
+
+// transport retorna a conexão aberta na n-ésima chamada (0 = Join)
+func (d *fakeDialer) transport(n int) *fakeTransport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if n >= len(d.transports) {
+		return nil
+	}
+	return d.transports[n]
+}
+
+func (d *fakeDialer) setFailing(failing bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failing = failing
+}
+
+// setHold faz as próximas chamadas esperarem até o canal retornado ser fechado
+func (d *fakeDialer) setHold() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hold = make(chan struct{})
+	return d.hold
+}
+
+func (d *fakeDialer) dials() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.calls
+}
+
+// eventually repete cond até ela passar ou o tempo acabar
+func eventually(t *testing.T, timeout time.Duration, msg string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout (%s): %s", timeout, msg)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,48 @@
+package voice
+
+import "math"
+
+// MaxVolume é o maior volume aceito (200%)
+const MaxVolume = 200
+
+// Acima deste nível (fração do fundo de escala) o soft clipping começa a atuar
+const softClipThreshold = 0.75
+
+// applyGain copia as amostras de src para dst aplicando um ganho linear (1 = original)
+// que vai de from no começo do frame a to no fim (rampas de fade; from == to para ganho fixo).
+// Acima de 1 a saída passa por soft clipping para evitar distorção dura.
+func applyGain(dst, src []int16, from, to float64) {
+	clip := from > 1 || to > 1
+	step := (to - from) / float64(len(src)/channels)
+	for i, s := range src {
+		gain := from + step*float64(i/channels)
+		v := float64(s) / 32768.0 * gain
+		if clip {
+			v = softClip(v)
+		}
+		dst[i] = toSample(v)
+	}
+}
+
+// softClip comprime suavemente amostras (normalizadas em -1..1) acima do limiar
+func softClip(v float64) float64 {
+	a := math.Abs(v)
+	if a <= softClipThreshold {
+		return v
+	}
+
+	knee := 1 - softClipThreshold
+	a = softClipThreshold + knee*math.Tanh((a-softClipThreshold)/knee)
+	return math.Copysign(a, v)
+}
+
+// toSample converte uma amostra normalizada para int16 com saturação
+func toSample(v float64) int16 {
+	v *= 32768.0
+	if v > 32767 {
+		return 32767
+	} else if v < -32768 {
+		return -32768
+	}
+	return int16(v)
+}
@@ -0,0 +1,75 @@
+package voice
+
+import (
+	"io"
+	"math"
+	"time"
+)
+
+// ToneSource gera uma senoide (Frequency 0 = silêncio). Útil para testar a
+// conexão de voz sem depender de arquivos.
+type ToneSource struct {
+	Frequency float64       // Hz
+	Amplitude float64       // 0..1
+	Length    time.Duration // 0 = infinito
+}
+
+// NewToneSource cria uma senoide com a frequência e duração pedidas
+func NewToneSource(frequency float64, length time.Duration) *ToneSource {
+	return &ToneSource{Frequency: frequency, Amplitude: 0.5, Length: length}
+}
+
+// NewSilenceSource cria uma fonte de silêncio com a duração pedida
+func NewSilenceSource(length time.Duration) *ToneSource {
+	return &ToneSource{Length: length}
+}
+
+func (t *ToneSource) Open(offset time.Duration) (FrameReader, error) {
+	if offset < 0 || (t.Length > 0 && offset > t.Length) {
+		return nil, ErrSeekOutOfRange
+	}
+
+	r := &toneReader{
+		source: t,
+		sample: int64(offset / frameDuration * frameSize),
+		pcm:    make([]int16, frameSize*channels),
+	}
+	if t.Length > 0 {
+		r.end = int64(t.Length/frameDuration) * frameSize
+	} else {
+		r.end = -1
+	}
+	return r, nil
+}
+
+func (t *ToneSource) Duration() time.Duration { return t.Length }
+
+func (t *ToneSource) Seekable() bool { return true }
+
+// toneReader calcula as amostras a partir da posição absoluta, então o seek é gratuito
+type toneReader struct {
+	source *ToneSource
+	sample int64 // Próxima amostra (por canal)
+	end    int64 // -1 = infinito
+	pcm    []int16
+}
+
+func (r *toneReader) ReadFrame() (Frame, error) {
+	if r.end >= 0 && r.sample >= r.end {
+		return Frame{}, io.EOF
+	}
+
+	step := 2 * math.Pi * r.source.Frequency / frameRate
+	amp := max(0, min(r.source.Amplitude, 1)) * math.MaxInt16
+	for i := 0; i < frameSize; i++ {
+		v := int16(amp * math.Sin(step*float64(r.sample+int64(i))))
+		r.pcm[i*channels] = v
+		r.pcm[i*channels+1] = v
+	}
+	r.sample += frameSize
+	return Frame{PCM: r.pcm}, nil
+}
+
+func (r *toneReader) Close() error {
+	return nil
+}
@@ -0,0 +1,266 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidURL             = errors.New("URL inválida, use um link http:// ou https://")
+	ErrUnsupportedContentType = errors.New("o link não aponta para um arquivo de áudio")
+	ErrTooLarge               = errors.New("arquivo de áudio maior que o limite permitido")
+)
+
+// HTTPLimits são os limites aplicados ao áudio baixado de URLs
+type HTTPLimits struct {
+	MaxBytes    int64         // Tamanho máximo do arquivo (0 = sem limite)
+	MaxDuration time.Duration // O áudio é cortado neste ponto (0 = sem limite)
+	Timeout     time.Duration // Conexão e cabeçalhos da resposta (0 = sem limite)
+	IdleTimeout time.Duration // Tempo máximo sem receber dados durante o stream (0 = sem limite)
+}
+
+// DefaultHTTPLimits são os limites usados pelas fontes criadas com NewHTTPSource
+var DefaultHTTPLimits = HTTPLimits{
+	MaxBytes:    50 << 20,
+	MaxDuration: 20 * time.Minute,
+	Timeout:     10 * time.Second,
+	IdleTimeout: 15 * time.Second,
+}
+
+// HTTPSource toca o áudio de uma URL, decodificando enquanto baixa.
+// Streams HTTP só tocam do início. O primeiro download que chega ao fim fica
+// em memória (até Limits.MaxBytes) e os loops seguintes tocam dele, sem baixar de novo.
+type HTTPSource struct {
+	URL     string
+	Client  *http.Client // nil = PublicHTTPClient (só endereços públicos)
+	Clock   Clock        // Relógio dos timeouts (nil = SystemClock)
+	Limits  HTTPLimits
+	Decoder Decoder
+
+	mu   sync.Mutex
+	data []byte // Bytes do download completo (nil = ainda não baixou até o fim)
+}
+
+// NewHTTPSource valida a URL e cria a fonte com os limites padrão
+func NewHTTPSource(rawURL string, decoder Decoder) (*HTTPSource, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidURL
+	}
+	return &HTTPSource{URL: u.String(), Limits: DefaultHTTPLimits, Decoder: decoder}, nil
+}
+
+// NewURLTrack cria uma faixa avulsa (fora da biblioteca) para a URL.
+// Sem título, usa o nome do arquivo no caminho da URL.
+func NewURLTrack(rawURL, title string, decoder Decoder) (*Track, error) {
+	src, err := NewHTTPSource(rawURL, decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	if title == "" {
+		u, _ := url.Parse(src.URL)
+		title = path.Base(u.Path)
+		if title == "/" || title == "." {
+			title = u.Host
+		}
+	}
+	return &Track{ID: src.URL, Title: title, Source: src}, nil
+}
+
+// CheckContentType aceita tipos de áudio e os genéricos usados por CDNs.
+// Cabeçalho vazio é aceito: o decoder decide pelo conteúdo.
+func CheckContentType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ErrUnsupportedContentType
+	}
+	if strings.HasPrefix(mediaType, "audio/") {
+		return nil
+	}
+	switch mediaType {
+	case "application/ogg", "application/octet-stream", "video/ogg":
+		return nil
+	}
+	return ErrUnsupportedContentType
+}
+
+func (h *HTTPSource) Open(offset time.Duration) (FrameReader, error) {
+	if offset != 0 {
+		return nil, ErrNotSeekable
+	}
+
+	h.mu.Lock()
+	data := h.data
+	h.mu.Unlock()
+	if data != nil {
+		reader, err := openDecoded(io.NopCloser(bytes.NewReader(data)), h.Decoder)
+		if err != nil {
+			return nil, err
+		}
+		return h.limit(reader), nil
+	}
+
+	client := h.Client
+	if client == nil {
+		client = PublicHTTPClient
+	}
+
+	// O watchdog cancela a requisição se a resposta (ou, depois, os dados) não chegar a tempo.
+	// http.Client.Timeout não serve aqui: ele limitaria a duração do stream inteiro.
+	clock := h.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	body := &httpBody{cancel: cancel, limits: h.Limits, watchdog: clock.AfterFunc(time.Hour, cancel), keep: h.Limits.MaxBytes > 0}
+	body.watchdog.Stop()
+	if h.Limits.Timeout > 0 {
+		body.watchdog.Reset(h.Limits.Timeout)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("erro ao baixar áudio: %w", err)
+	}
+	body.body = resp.Body
+	body.watchdog.Stop()
+
+	if resp.StatusCode != http.StatusOK {
+		body.Close()
+		return nil, fmt.Errorf("erro ao baixar áudio: status %s", resp.Status)
+	}
+	if err := CheckContentType(resp.Header.Get("Content-Type")); err != nil {
+		body.Close()
+		return nil, err
+	}
+	if h.Limits.MaxBytes > 0 && resp.ContentLength > h.Limits.MaxBytes {
+		body.Close()
+		return nil, ErrTooLarge
+	}
+
+	reader, err := openDecoded(body, h.Decoder)
+	if err != nil {
+		return nil, err
+	}
+	if !body.keep {
+		return h.limit(reader), nil
+	}
+	return &keepFrameReader{FrameReader: h.limit(reader), body: body, src: h}, nil
+}
+
+// limit aplica o limite de duração ao stream
+func (h *HTTPSource) limit(reader FrameReader) FrameReader {
+	if h.Limits.MaxDuration <= 0 {
+		return reader
+	}
+	return &limitedFrameReader{FrameReader: reader, left: int(h.Limits.MaxDuration / frameDuration)}
+}
+
+func (h *HTTPSource) Duration() time.Duration { return 0 }
+
+func (h *HTTPSource) Seekable() bool { return false }
+
+// httpBody aplica o limite de tamanho e o timeout de inatividade ao corpo da resposta
+type httpBody struct {
+	body     io.ReadCloser
+	cancel   context.CancelFunc
+	watchdog Timer
+	limits   HTTPLimits
+	read     int64
+	once     sync.Once
+	keep     bool   // Guarda os bytes lidos para os próximos loops
+	kept     []byte // Limitado por MaxBytes: o Read falha antes de passar dele
+	err      error  // Primeiro erro do corpo além do io.EOF (limite, timeout, conexão)
+}
+
+// Read só conta o tempo esperando o servidor: pausas do player não disparam o timeout.
+func (b *httpBody) Read(p []byte) (int, error) {
+	if b.limits.IdleTimeout > 0 {
+		b.watchdog.Reset(b.limits.IdleTimeout)
+		defer b.watchdog.Stop()
+	}
+
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	if b.keep {
+		b.kept = append(b.kept, p[:n]...)
+	}
+	if b.limits.MaxBytes > 0 && b.read > b.limits.MaxBytes {
+		err = ErrTooLarge
+	}
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *httpBody) Close() error {
+	var err error
+	b.once.Do(func() {
+		b.watchdog.Stop()
+		if b.body != nil {
+			err = b.body.Close()
+		}
+		b.cancel()
+	})
+	return err
+}
+
+// limitedFrameReader corta o stream depois de left frames (limite de duração)
+type limitedFrameReader struct {
+	FrameReader
+	left int
+}
+
+func (r *limitedFrameReader) ReadFrame() (Frame, error) {
+	if r.left <= 0 {
+		slog.Warn("Stream atingiu a duração máxima, cortando")
+		return Frame{}, io.EOF
+	}
+	r.left--
+	return r.FrameReader.ReadFrame()
+}
+
+// keepFrameReader guarda na fonte os bytes baixados quando o stream termina sem erro.
+// Os bytes lidos até o fim (ou até o corte de duração) bastam para repetir os mesmos frames.
+// O corpo é conferido também: um decoder que tratasse o erro do download como fim do
+// arquivo não pode fazer um download cortado virar a faixa inteira.
+type keepFrameReader struct {
+	FrameReader
+	body *httpBody
+	src  *HTTPSource
+}
+
+func (r *keepFrameReader) ReadFrame() (Frame, error) {
+	frame, err := r.FrameReader.ReadFrame()
+	if err == io.EOF && r.body.keep && r.body.err == nil {
+		r.src.mu.Lock()
+		r.src.data = r.body.kept
+		r.src.mu.Unlock()
+		r.body.keep = false
+	}
+	return frame, err
+}
@@ -0,0 +1,337 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testWAV gera um WAV 48kHz estéreo de silêncio com a duração pedida
+func testWAV(d time.Duration) []byte {
+	samples := int(d / frameDuration * frameSize)
+	dataSize := samples * channels * 2
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []any{
+		uint32(16), uint16(1), uint16(channels), uint32(frameRate),
+		uint32(frameRate * channels * 2), uint16(channels * 2), uint16(16),
+	} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
+
+// serveAudio sobe um servidor local que responde o corpo com o content-type dado
+func serveAudio(t *testing.T, contentType string, body []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestSource(t *testing.T, srv *httptest.Server, path string) *HTTPSource {
+	t.Helper()
+	src, err := NewHTTPSource(srv.URL+path, nativeDecoder{})
+	if err != nil {
+		t.Fatalf("NewHTTPSource: %v", err)
+	}
+	src.Client = srv.Client()
+	return src
+}
+
+// countFrames lê a fonte até o fim e retorna quantos frames vieram
+func countFrames(src AudioSource) (int, error) {
+	r, err := src.Open(0)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	n := 0
+	for {
+		if _, err := r.ReadFrame(); err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+func TestHTTPSourceStreamsWAV(t *testing.T) {
+	srv := serveAudio(t, "audio/wav", testWAV(time.Second))
+
+	n, err := countFrames(newTestSource(t, srv, "/faixa.wav"))
+	if err != nil {
+		t.Fatalf("erro lendo stream: %v", err)
+	}
+	if n != 50 {
+		t.Errorf("frames = %d, esperado 50", n)
+	}
+}
+
+func TestHTTPSourceRejectsContentType(t *testing.T) {
+	srv := serveAudio(t, "text/html; charset=utf-8", []byte("<html></html>"))
+
+	if _, err := newTestSource(t, srv, "/").Open(0); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("err = %v, esperado ErrUnsupportedContentType", err)
+	}
+}
+
+func TestHTTPSourceStatus(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	if _, err := newTestSource(t, srv, "/nada.mp3").Open(0); err == nil {
+		t.Error("esperado erro para 404")
+	}
+}
+
+func TestHTTPSourceSizeLimit(t *testing.T) {
+	wav := testWAV(time.Second)
+
+	t.Run("content-length", func(t *testing.T) {
+		src := newTestSource(t, serveAudio(t, "audio/wav", wav), "/")
+		src.Limits.MaxBytes = int64(len(wav) / 2)
+
+		if _, err := src.Open(0); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("err = %v, esperado ErrTooLarge", err)
+		}
+	})
+
+	// Sem Content-Length o limite só é percebido durante o stream. O WAV de 44.1kHz
+	// passa pelo conversor de taxa, que precisa repassar o erro em vez de um fim limpo.
+	wav44 := wavFile(fmtChunk(1, 2, 44100, 16, false), wavChunk{"data", make([]byte, 44100*4)})
+	for name, body := range map[string][]byte{"chunked": wav, "chunked 44.1kHz": wav44} {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Content-Type", "audio/wav")
+				for off := 0; off < len(body); off += 4096 {
+					w.Write(body[off:min(off+4096, len(body))])
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer srv.Close()
+
+			src := newTestSource(t, srv, "/")
+			src.Limits.MaxBytes = int64(len(body) / 2)
+
+			for pass := range 2 {
+				if _, err := countFrames(src); !errors.Is(err, ErrTooLarge) {
+					t.Errorf("passada %d: err = %v, esperado ErrTooLarge", pass, err)
+				}
+			}
+			// O download cortado pelo limite não fica guardado para os loops
+			if got := requests.Load(); got != 2 {
+				t.Errorf("%d downloads, esperado 2", got)
+			}
+		})
+	}
+}
+
+func TestHTTPSourceDurationLimit(t *testing.T) {
+	src := newTestSource(t, serveAudio(t, "audio/wav", testWAV(time.Second)), "/")
+	src.Limits.MaxDuration = 200 * time.Millisecond
+
+	n, err := countFrames(src)
+	if err != nil {
+		t.Fatalf("erro lendo stream: %v", err)
+	}
+	if n != 10 {
+		t.Errorf("frames = %d, esperado 10", n)
+	}
+}
+
+func TestHTTPSourceTimeouts(t *testing.T) {
+	wav := testWAV(time.Second)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	t.Run("cabecalho", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer srv.Close()
+
+		src := newTestSource(t, srv, "/")
+		src.Limits.Timeout = 50 * time.Millisecond
+
+		if _, err := src.Open(0); err == nil {
+			t.Error("esperado erro de timeout")
+		}
+	})
+
+	t.Run("inatividade", func(t *testing.T) {
+		// Manda metade do arquivo e trava
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "audio/wav")
+			w.Write(wav[:len(wav)/2])
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer srv.Close()
+
+		src := newTestSource(t, srv, "/")
+		src.Limits.IdleTimeout = 50 * time.Millisecond
+
+		if _, err := countFrames(src); err == nil {
+			t.Error("esperado erro de inatividade")
+		}
+	})
+}
+
+func TestHTTPSourceNotSeekable(t *testing.T) {
+	src := newTestSource(t, serveAudio(t, "audio/wav", testWAV(time.Second)), "/")
+
+	if src.Seekable() {
+		t.Error("stream HTTP não deveria permitir seek")
+	}
+	if _, err := src.Open(time.Second); !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("err = %v, esperado ErrNotSeekable", err)
+	}
+}
+
+func TestNewURLTrack(t *testing.T) {
+	for _, raw := range []string{"", "ftp://exemplo.com/a.mp3", "file:///etc/passwd", "https://"} {
+		if _, err := NewURLTrack(raw, "", nil); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("NewURLTrack(%q) err = %v, esperado ErrInvalidURL", raw, err)
+		}
+	}
+
+	track, err := NewURLTrack("https://exemplo.com/musicas/hakari.mp3?x=1", "", nil)
+	if err != nil {
+		t.Fatalf("NewURLTrack: %v", err)
+	}
+	if track.Title != "hakari.mp3" {
+		t.Errorf("título = %q, esperado hakari.mp3", track.Title)
+	}
+}
+
+func TestHTTPSourceBlocksInternalAddresses(t *testing.T) {
+	srv := serveAudio(t, "audio/wav", testWAV(time.Second))
+	src, err := NewHTTPSource(srv.URL+"/", nativeDecoder{})
+	if err != nil {
+		t.Fatalf("NewHTTPSource: %v", err)
+	}
+	// Sem Client injetado vale o PublicHTTPClient, que recusa o 127.0.0.1 do httptest
+	if _, err := src.Open(0); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("err = %v, esperado ErrBlockedAddress", err)
+	}
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"1.1.1.1", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.0.10", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %v, esperado %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	req := func(raw string) *http.Request {
+		r, err := http.NewRequest(http.MethodGet, raw, nil)
+		if err != nil {
+			t.Fatalf("NewRequest(%q): %v", raw, err)
+		}
+		return r
+	}
+
+	if err := checkRedirect(req("https://exemplo.com/b.mp3"), make([]*http.Request, 1)); err != nil {
+		t.Errorf("redirecionamento público recusado: %v", err)
+	}
+	if err := checkRedirect(req("http://169.254.169.254/latest/meta-data"), make([]*http.Request, 1)); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("redirecionamento para o metadata err = %v, esperado ErrBlockedAddress", err)
+	}
+	if err := checkRedirect(req("ftp://exemplo.com/b.mp3"), make([]*http.Request, 1)); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("redirecionamento para ftp err = %v, esperado ErrInvalidURL", err)
+	}
+	if err := checkRedirect(req("https://exemplo.com/b.mp3"), make([]*http.Request, maxRedirects)); err == nil {
+		t.Error("redirecionamento além do limite deveria falhar")
+	}
+}
+
+func TestHTTPSourceLoopsFromMemory(t *testing.T) {
+	body := testWAV(time.Second)
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	src := newTestSource(t, srv, "/")
+
+	for pass := range 3 {
+		n, err := countFrames(src)
+		if err != nil {
+			t.Fatalf("passada %d: %v", pass, err)
+		}
+		if n != 50 {
+			t.Errorf("passada %d: %d frames, esperado 50", pass, n)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("%d downloads, esperado 1: os loops deveriam tocar da memória", got)
+	}
+
+	// Download interrompido não fica guardado
+	src = newTestSource(t, srv, "/")
+	r, err := src.Open(0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	r.ReadFrame()
+	r.Close()
+	if _, err := countFrames(src); err != nil {
+		t.Fatalf("segunda leitura: %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("%d downloads, esperado 3: o stream fechado no meio não deveria ser reaproveitado", got)
+	}
+}
@@ -0,0 +1,46 @@
+package voice
+
+import "sync"
+
+// hub distribui eventos para os assinantes sem bloquear quem publica. O valor zero
+// está pronto para uso.
+type hub[T any] struct {
+	mu   sync.Mutex
+	subs map[chan T]struct{}
+}
+
+// subscribe cria uma assinatura com o buffer pedido; cancel a encerra e fecha o canal
+func (h *hub[T]) subscribe(buffer int) (<-chan T, func()) {
+	ch := make(chan T, buffer)
+
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan T]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish entrega ev a todos os assinantes. Um assinante lento (buffer cheio) perde
+// o evento; retorna quantos perderam.
+func (h *hub[T]) publish(ev T) (dropped int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
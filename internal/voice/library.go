@@ -0,0 +1,189 @@
+package voice
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// JackpotTrackID é a faixa tocada pelo /jackpot (Tuca Donka)
+const JackpotTrackID = "tuca-donka"
+
+// Extensões aceitas ao escanear o diretório de áudio
+var supportedExtensions = map[string]bool{
+	".mp3":  true,
+	".wav":  true,
+	".ogg":  true,
+	".opus": true,
+	".flac": true,
+	".m4a":  true,
+	".dca":  true,
+}
+
+// Track é uma faixa registrada na biblioteca
+type Track struct {
+	ID       string // Nome do arquivo sem extensão (ex: "tuca-donka")
+	Title    string
+	Duration time.Duration // 0 = desconhecida (streams)
+	Loudness float64       // Loudness integrada em LUFS, medida no carregamento (0 = não medida)
+	Source   AudioSource
+}
+
+// Library é o registro de faixas disponíveis, carregado na inicialização
+type Library struct {
+	tracks []*Track // Ordenadas por ID
+	byID   map[string]*Track
+}
+
+// LoadLibrary escaneia o diretório e prepara cada arquivo suportado. Ogg/Opus e DCA
+// ficam na memória em passthrough: são os pacotes do próprio arquivo, do tamanho
+// dele. Os demais formatos tocam do disco pelo decoder (FileSource); aqui eles são
+// lidos uma vez só para medir duração e loudness.
+func LoadLibrary(dir string, decoder Decoder) (*Library, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler diretório de áudio: %w", err)
+	}
+
+	lib := &Library{byID: make(map[string]*Track)}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !supportedExtensions[ext] {
+			continue
+		}
+
+		id := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if _, ok := lib.byID[id]; ok {
+			slog.Warn("Faixa duplicada ignorada", "track_id", id, "file", entry.Name())
+			continue
+		}
+
+		track, err := loadTrack(filepath.Join(dir, entry.Name()), id, ext, decoder)
+		if err != nil {
+			slog.Warn("Erro ao carregar faixa, ignorando", "file", entry.Name(), "error", err)
+			continue
+		}
+		lib.tracks = append(lib.tracks, track)
+		lib.byID[id] = track
+		_, inMemory := track.Source.(*Audio)
+		slog.Info("Faixa carregada", "track_id", id, "duration", track.Duration, "memory", inMemory, "lufs", fmt.Sprintf("%.1f", track.Loudness))
+	}
+
+	if len(lib.tracks) == 0 {
+		return nil, fmt.Errorf("nenhuma faixa de áudio encontrada em %s", dir)
+	}
+
+	sort.Slice(lib.tracks, func(a, b int) bool { return lib.tracks[a].ID < lib.tracks[b].ID })
+	return lib, nil
+}
+
+// loadTrack prepara a faixa do arquivo e mede a loudness uma única vez; sem
+// medição a faixa toca sem normalização
+func loadTrack(path, id, ext string, decoder Decoder) (*Track, error) {
+	track := &Track{ID: id, Title: titleFromID(id)}
+
+	audio, err := loadPassthrough(path, ext)
+	if err != nil {
+		return nil, err
+	}
+	if audio != nil {
+		track.Source, track.Duration = audio, audio.Duration()
+		if track.Loudness, err = MeasureLoudness(audio); err != nil {
+			slog.Warn("Não foi possível medir a loudness da faixa", "track_id", id, "error", err)
+		}
+		return track, nil
+	}
+
+	// A leitura de medição também confirma que o decoder entende o arquivo
+	src := NewFileSource(path, 0, decoder)
+	lufs, frames, err := measureLoudness(src)
+	if err != nil && !errors.Is(err, errTooQuiet) {
+		return nil, err
+	}
+	if frames == 0 {
+		return nil, fmt.Errorf("faixa sem áudio")
+	}
+	if err != nil {
+		slog.Warn("Não foi possível medir a loudness da faixa", "track_id", id, "error", err)
+	}
+	src.duration = time.Duration(frames) * frameDuration
+	track.Source, track.Duration, track.Loudness = src, src.duration, lufs
+	return track, nil
+}
+
+// loadPassthrough carrega o arquivo na memória se ele puder tocar em passthrough
+// (nil, nil nos demais casos)
+func loadPassthrough(path, ext string) (*Audio, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 64)
+	n, _ := io.ReadFull(file, header)
+	file.Close()
+
+	// DCA legado (sem cabeçalho DCA1) só é reconhecido pela extensão
+	format := detectFormat(header[:n])
+	if ext != ".dca" && format != formatDCA && format != formatOggOpus {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if format == formatOggOpus {
+		// Frames fora do padrão do Discord tocam do disco pelo decoder
+		audio, err := newOggPassthrough(data)
+		if err != nil {
+			return nil, nil
+		}
+		return audio, nil
+	}
+	return NewDCAAudio(data)
+}
+
+// Get retorna a faixa pelo ID (nil se não existir)
+func (l *Library) Get(id string) *Track {
+	return l.byID[id]
+}
+
+// All retorna todas as faixas ordenadas por ID
+func (l *Library) All() []*Track {
+	return l.tracks
+}
+
+// Search retorna até limit faixas cujo ID ou título contém a busca (sem diferenciar maiúsculas).
+// Busca vazia retorna as primeiras faixas.
+func (l *Library) Search(query string, limit int) []*Track {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var result []*Track
+	for _, t := range l.tracks {
+		if len(result) >= limit {
+			break
+		}
+		if query == "" || strings.Contains(strings.ToLower(t.ID), query) || strings.Contains(strings.ToLower(t.Title), query) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// titleFromID gera um título legível a partir do nome do arquivo ("tuca-donka" -> "Tuca Donka")
+func titleFromID(id string) string {
+	words := strings.FieldsFunc(id, func(r rune) bool { return r == '-' || r == '_' || r == ' ' })
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
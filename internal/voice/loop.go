@@ -0,0 +1,130 @@
+package voice
+
+import (
+	"errors"
+	"math/rand/v2"
+)
+
+// ErrInvalidLoopMode é retornado para nomes de modo desconhecidos
+var ErrInvalidLoopMode = errors.New("modo de loop inválido")
+
+// LoopMode define o que acontece quando uma faixa termina seus loops
+type LoopMode int
+
+const (
+	// LoopOff: cada faixa toca as quantas-vezes pedidas (vazio = infinito) e a fila segue
+	LoopOff LoopMode = iota
+	// LoopOne: repete a faixa atual para sempre, ignorando o quantas-vezes
+	LoopOne
+	// LoopQueue: a faixa terminada volta para o fim da fila
+	LoopQueue
+	// LoopShuffle: como LoopQueue, mas a próxima faixa é sorteada da fila
+	LoopShuffle
+)
+
+// loopModeNames são os nomes exibidos e aceitos pelo /loop, na ordem do botão do painel
+var loopModeNames = []string{"desligado", "faixa", "fila", "aleatório"}
+
+func (m LoopMode) String() string {
+	if m < 0 || int(m) >= len(loopModeNames) {
+		return "?"
+	}
+	return loopModeNames[m]
+}
+
+// ParseLoopMode converte o nome exibido de volta para o modo
+func ParseLoopMode(name string) (LoopMode, error) {
+	for i, n := range loopModeNames {
+		if n == name {
+			return LoopMode(i), nil
+		}
+	}
+	return LoopOff, ErrInvalidLoopMode
+}
+
+// LoopModes lista todos os modos na ordem do botão do painel
+func LoopModes() []LoopMode {
+	return []LoopMode{LoopOff, LoopOne, LoopQueue, LoopShuffle}
+}
+
+// SetLoopMode muda o modo durante o playback. Sair do LoopOne com uma faixa em
+// repetição infinita faz ela terminar no fim do loop em andamento. Pedir o modo
+// que já está valendo não muda nada.
+func (sess *Session) SetLoopMode(mode LoopMode) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if mode == sess.loopMode {
+		return
+	}
+	if sess.loopMode == LoopOne && sess.loops <= 0 {
+		sess.loops = sess.loop + 1
+	}
+	sess.loopMode = mode
+	if mode == LoopShuffle {
+		sess.shuffleNextLocked()
+	}
+}
+
+// CycleLoopMode avança para o próximo modo (botão do painel) e retorna o novo modo
+func (sess *Session) CycleLoopMode() LoopMode {
+	mode := (sess.LoopMode() + 1) % LoopMode(len(loopModeNames))
+	sess.SetLoopMode(mode)
+	return mode
+}
+
+func (sess *Session) LoopMode() LoopMode {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.loopMode
+}
+
+func (sess *Session) currentLoop() int {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.loop
+}
+
+// advanceLoop conta o loop concluído e indica se a faixa deve tocar de novo
+func (sess *Session) advanceLoop() bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	sess.loop++
+	return !sess.finalLoopLocked(sess.loop - 1)
+}
+
+// finalLoopLocked indica se o loop n (começando em 0) é o último da faixa atual.
+// Nos modos de fila uma faixa infinita toca uma vez por volta.
+func (sess *Session) finalLoopLocked(n int) bool {
+	switch {
+	case sess.loopMode == LoopOne:
+		return false
+	case sess.loops <= 0:
+		return sess.loopMode == LoopQueue || sess.loopMode == LoopShuffle
+	default:
+		return n+1 >= sess.loops
+	}
+}
+
+// requeueLocked devolve a faixa terminada para o fim da fila nos modos de fila.
+// A posição inicial só vale na primeira vez.
+func (sess *Session) requeueLocked(finished *QueueItem) {
+	if finished == nil || (sess.loopMode != LoopQueue && sess.loopMode != LoopShuffle) {
+		return
+	}
+	again := *finished
+	again.Start = 0
+	sess.queue = append(sess.queue, &again)
+}
+
+// shuffleNextLocked sorteia a próxima faixa e a coloca no início da fila.
+// A última posição fica de fora do sorteio: é a faixa que acabou de voltar para
+// a fila, e repeti-la em seguida não parece aleatório.
+func (sess *Session) shuffleNextLocked() {
+	if len(sess.queue) < 3 {
+		return
+	}
+	j := rand.IntN(len(sess.queue) - 1)
+	sess.queue[0], sess.queue[j] = sess.queue[j], sess.queue[0]
+}
@@ -0,0 +1,164 @@
+package voice
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFinalLoop(t *testing.T) {
+	tests := []struct {
+		mode  LoopMode
+		loops int
+		n     int
+		want  bool
+	}{
+		{LoopOff, 3, 0, false},
+		{LoopOff, 3, 1, false},
+		{LoopOff, 3, 2, true},
+		{LoopOff, 1, 0, true},
+		// Infinita fora dos modos de fila nunca acaba
+		{LoopOff, 0, 0, false},
+		{LoopOff, 0, 99, false},
+		// Nos modos de fila uma faixa infinita toca uma vez por volta
+		{LoopQueue, 0, 0, true},
+		{LoopShuffle, 0, 0, true},
+		{LoopQueue, 2, 0, false},
+		{LoopQueue, 2, 1, true},
+		{LoopShuffle, 2, 1, true},
+		// LoopOne ignora o quantas-vezes
+		{LoopOne, 1, 0, false},
+		{LoopOne, 0, 5, false},
+	}
+	for _, tt := range tests {
+		sess := &Session{loopMode: tt.mode, loops: tt.loops}
+		if got := sess.finalLoopLocked(tt.n); got != tt.want {
+			t.Errorf("modo %s, loops %d: finalLoopLocked(%d) = %v, esperado %v", tt.mode, tt.loops, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSetLoopModeEndsInfinite(t *testing.T) {
+	tests := []struct {
+		from      LoopMode
+		mode      LoopMode
+		loops     int
+		loop      int
+		wantLoops int
+	}{
+		// Faixa infinita termina no fim do loop em andamento ao sair do LoopOne
+		{LoopOne, LoopOff, 0, 0, 1},
+		{LoopOne, LoopOff, 0, 4, 5},
+		{LoopOne, LoopQueue, 0, 2, 3},
+		{LoopOne, LoopShuffle, 0, 2, 3},
+		// Entre os outros modos a repetição infinita (/jackpot) continua
+		{LoopOff, LoopQueue, 0, 2, 0},
+		{LoopQueue, LoopOff, 0, 2, 0},
+		{LoopOff, LoopOne, 0, 2, 0},
+		// O mesmo modo de novo não muda nada
+		{LoopOff, LoopOff, 0, 2, 0},
+		{LoopQueue, LoopQueue, 0, 2, 0},
+		{LoopOne, LoopOne, 0, 2, 0},
+		// Quantas-vezes pedido continua valendo
+		{LoopOne, LoopOff, 3, 1, 3},
+		{LoopOne, LoopQueue, 3, 1, 3},
+	}
+	for _, tt := range tests {
+		sess := &Session{loops: tt.loops, loop: tt.loop, loopMode: tt.from}
+		sess.SetLoopMode(tt.mode)
+		if sess.loops != tt.wantLoops || sess.loopMode != tt.mode {
+			t.Errorf("SetLoopMode de %s para %s com loops %d no loop %d: loops = %d, modo %s, esperado %d", tt.from, tt.mode, tt.loops, tt.loop, sess.loops, sess.loopMode, tt.wantLoops)
+		}
+	}
+
+	// Chamar duas vezes com o mesmo modo equivale a uma
+	sess := &Session{loops: 0, loop: 1, loopMode: LoopOne}
+	sess.SetLoopMode(LoopOff)
+	sess.SetLoopMode(LoopOff)
+	if sess.loops != 2 {
+		t.Errorf("LoopOff duas vezes: loops = %d, esperado 2", sess.loops)
+	}
+}
+
+// queueOf monta uma fila com itens identificados pelo ID da faixa
+func queueOf(n int) []*QueueItem {
+	queue := make([]*QueueItem, n)
+	for i := range queue {
+		queue[i] = &QueueItem{Track: &Track{ID: fmt.Sprint(i)}, Loops: 1}
+	}
+	return queue
+}
+
+func queueIDs(queue []*QueueItem) string {
+	ids := ""
+	for _, item := range queue {
+		ids += item.Track.ID
+	}
+	return ids
+}
+
+func TestRequeue(t *testing.T) {
+	finished := &QueueItem{Track: &Track{ID: "x"}, Loops: 2, Start: 30}
+	tests := []struct {
+		mode     LoopMode
+		finished *QueueItem
+		want     string
+	}{
+		{LoopOff, finished, "01"},
+		{LoopOne, finished, "01"},
+		{LoopQueue, finished, "01x"},
+		{LoopShuffle, finished, "01x"},
+		{LoopQueue, nil, "01"},
+	}
+	for _, tt := range tests {
+		sess := &Session{loopMode: tt.mode, queue: queueOf(2)}
+		sess.requeueLocked(tt.finished)
+		if got := queueIDs(sess.queue); got != tt.want {
+			t.Errorf("modo %s: fila = %q, esperado %q", tt.mode, got, tt.want)
+		}
+	}
+
+	// A cópia volta para o início da faixa; o item original não muda
+	sess := &Session{loopMode: LoopQueue}
+	sess.requeueLocked(finished)
+	if again := sess.queue[0]; again == finished || again.Start != 0 || again.Loops != 2 || finished.Start != 30 {
+		t.Errorf("item devolvido = %+v (original %+v), esperado uma cópia com Start 0", again, finished)
+	}
+}
+
+func TestShuffleNext(t *testing.T) {
+	tests := []struct {
+		size     int
+		possible string // Faixas que podem ser sorteadas para o início
+	}{
+		// Abaixo de 3 itens não há o que sortear sem repetir a que acabou de voltar
+		{0, ""},
+		{1, "0"},
+		{2, "0"},
+		// A última posição fica de fora do sorteio
+		{3, "01"},
+		{5, "0123"},
+	}
+	for _, tt := range tests {
+		seen := map[string]bool{}
+		for range 200 {
+			sess := &Session{queue: queueOf(tt.size)}
+			sess.shuffleNextLocked()
+			if tt.size == 0 {
+				break
+			}
+			ids := queueIDs(sess.queue)
+			if ids[len(ids)-1] != byte('0'+tt.size-1) {
+				t.Fatalf("fila de %d: %q, a última faixa saiu do lugar", tt.size, ids)
+			}
+			seen[ids[:1]] = true
+		}
+		for _, id := range tt.possible {
+			if !seen[string(id)] {
+				t.Errorf("fila de %d: faixa %c nunca foi sorteada", tt.size, id)
+			}
+		}
+		if len(seen) > len(tt.possible) {
+			t.Errorf("fila de %d: sorteadas %v, esperado só %q", tt.size, seen, tt.possible)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package voice
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+// DefaultTargetLUFS é o alvo padrão da normalização (o mesmo dos serviços de streaming)
+const DefaultTargetLUFS = -14.0
+
+// Limites do ganho de normalização: faixas muito baixas não são infladas além disso
+const (
+	maxNormalizationDB = 9
+	minNormalizationDB = -20
+)
+
+// NormalizationDB retorna o ganho (em dB) que leva a faixa ao alvo (em LUFS).
+// Faixas sem medição (streams) ou com a normalização desligada (alvo 0) ficam em 0 dB.
+func (t *Track) NormalizationDB(target float64) float64 {
+	if target == 0 || t.Loudness == 0 {
+		return 0
+	}
+	return max(minNormalizationDB, min(target-t.Loudness, maxNormalizationDB))
+}
+
+// NormalizationGain retorna o ganho linear de normalização da faixa
+func (t *Track) NormalizationGain(target float64) float64 {
+	db := t.NormalizationDB(target)
+	// Diferenças inaudíveis não valem perder o passthrough dos frames prontos
+	if math.Abs(db) < 0.1 {
+		return 1
+	}
+	return math.Pow(10, db/20)
+}
+
+// Coeficientes do filtro K (ITU-R BS.1770) a 48kHz: pré-filtro shelving + high-pass RLB
+func newKWeighting() chain {
+	return chain{
+		&biquad{b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285, a1: -1.69065929318241, a2: 0.73248077421585},
+		&biquad{b0: 1, b1: -2, b2: 1, a1: -1.99004745483398, a2: 0.99007225036621},
+	}
+}
+
+const (
+	loudnessSegment  = frameRate / 10 // 100ms: blocos de 400ms com 75% de sobreposição
+	absoluteGateLUFS = -70
+	relativeGateLU   = -10
+)
+
+// errTooQuiet é o erro da medição de fontes sem nenhum bloco acima do gate
+var errTooQuiet = errors.New("áudio silencioso ou curto demais para medir loudness")
+
+// MeasureLoudness lê a fonte inteira e calcula a loudness integrada (EBU R128 / BS.1770),
+// em LUFS. Fontes em silêncio (nenhum bloco acima do gate) retornam erro.
+func MeasureLoudness(src AudioSource) (float64, error) {
+	lufs, _, err := measureLoudness(src)
+	return lufs, err
+}
+
+// measureLoudness é o MeasureLoudness que também conta os frames lidos: a duração
+// das fontes que só a descobrem lendo até o fim. Em errTooQuiet a contagem vale.
+func measureLoudness(src AudioSource) (float64, int, error) {
+	reader, err := src.Open(0)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer reader.Close()
+
+	var (
+		decoder  opusDecoder
+		filter   = newKWeighting()
+		buf      = make([]float64, frameSize*channels)
+		segments []float64 // Soma dos quadrados (todos os canais) de cada 100ms
+		sum      float64
+		count    int
+		frames   int
+	)
+	for {
+		f, err := reader.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, frames, err
+		}
+		frames++
+
+		pcm := f.PCM
+		if pcm == nil {
+			if pcm, err = decoder.decode(f.Opus); err != nil {
+				return 0, frames, err
+			}
+		}
+		for i, s := range pcm {
+			buf[i] = float64(s) / 32768.0
+		}
+		filter.process(buf)
+
+		for i := 0; i < len(buf); i += channels {
+			for c := 0; c < channels; c++ {
+				sum += buf[i+c] * buf[i+c]
+			}
+			if count++; count == loudnessSegment {
+				segments = append(segments, sum)
+				sum, count = 0, 0
+			}
+		}
+	}
+
+	// Energia média de cada bloco de 400ms (4 segmentos)
+	var blocks []float64
+	for i := 0; i+4 <= len(segments); i++ {
+		z := (segments[i] + segments[i+1] + segments[i+2] + segments[i+3]) / (4 * loudnessSegment)
+		if blockLoudness(z) > absoluteGateLUFS {
+			blocks = append(blocks, z)
+		}
+	}
+	if len(blocks) == 0 {
+		return 0, frames, errTooQuiet
+	}
+
+	relativeGate := blockLoudness(mean(blocks)) + relativeGateLU
+	var gated []float64
+	for _, z := range blocks {
+		if blockLoudness(z) > relativeGate {
+			gated = append(gated, z)
+		}
+	}
+	return blockLoudness(mean(gated)), frames, nil
+}
+
+func blockLoudness(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
+
+func mean(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
@@ -0,0 +1,145 @@
+package voice
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Manager guarda as sessões de voz de um bot, uma por servidor. Cada Manager é
+// isolado: vários podem rodar no mesmo processo (e nos testes).
+type Manager struct {
+	sessions map[string]*Session
+	mu       sync.RWMutex
+
+	dial       VoiceDialer
+	decoder    Decoder
+	targetLUFS float64  // Alvo da normalização de loudness (0 = desligada)
+	library    *Library // Faixas do LoadLibrary (vazia até lá)
+	clock      Clock
+	log        *slog.Logger
+	retry      Retry
+	timeouts   Timeouts
+
+	states     hub[StateEvent]
+	reconnects hub[ReconnectEvent]
+	breakers   map[string]*breaker // Disjuntor de reconexão por servidor (sobrevive às sessões)
+}
+
+// Timeouts são as esperas do manager e dos players
+type Timeouts struct {
+	Ready      time.Duration // Handshake de voz (IP Discovery e criptografia) ao iniciar o player
+	Migration  time.Duration // Espera pela conexão no servidor novo de uma migração antes de partir para a reconexão
+	LeaveDelay time.Duration // Espera depois do fim do playback antes de sair do canal
+}
+
+var DefaultTimeouts = Timeouts{
+	Ready:      10 * time.Second,
+	Migration:  8 * time.Second,
+	LeaveDelay: time.Second,
+}
+
+// Option configura o Manager no NewManager
+type Option func(*Manager)
+
+// WithDiscord conecta pelo gateway da sessão do Discord
+func WithDiscord(s *discordgo.Session) Option {
+	return func(m *Manager) { m.dial = DiscordDialer(s) }
+}
+
+// WithDialer troca a forma de abrir conexões de voz (conexões em memória nos testes)
+func WithDialer(dial VoiceDialer) Option {
+	return func(m *Manager) { m.dial = dial }
+}
+
+// WithDecoder define o decoder das faixas abertas pelo manager (padrão: NewAutoDecoder)
+func WithDecoder(decoder Decoder) Option {
+	return func(m *Manager) { m.decoder = decoder }
+}
+
+// WithTargetLUFS define o alvo da normalização de loudness (padrão: DefaultTargetLUFS, 0 = desligada)
+func WithTargetLUFS(lufs float64) Option {
+	return func(m *Manager) { m.targetLUFS = lufs }
+}
+
+func WithClock(clock Clock) Option {
+	return func(m *Manager) { m.clock = clock }
+}
+
+func WithLogger(log *slog.Logger) Option {
+	return func(m *Manager) { m.log = log }
+}
+
+func WithRetry(retry Retry) Option {
+	return func(m *Manager) { m.retry = retry }
+}
+
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(m *Manager) { m.timeouts = timeouts }
+}
+
+// NewManager cria um manager vazio. Sem WithDiscord (ou WithDialer) o Join falha.
+func NewManager(opts ...Option) *Manager {
+	m := &Manager{
+		sessions:   make(map[string]*Session),
+		breakers:   make(map[string]*breaker),
+		decoder:    NewAutoDecoder(),
+		targetLUFS: DefaultTargetLUFS,
+		library:    &Library{byID: make(map[string]*Track)},
+		clock:      SystemClock,
+		log:        slog.Default(),
+		retry:      DefaultRetry,
+		timeouts:   DefaultTimeouts,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Decoder retorna o decoder usado nas faixas do manager
+func (m *Manager) Decoder() Decoder {
+	return m.decoder
+}
+
+// TargetLUFS retorna o alvo da normalização de loudness (0 = desligada)
+func (m *Manager) TargetLUFS() float64 {
+	return m.targetLUFS
+}
+
+// LoadLibrary carrega as faixas do diretório com o decoder do manager. A biblioteca
+// anterior continua valendo se o carregamento falhar.
+func (m *Manager) LoadLibrary(dir string) error {
+	lib, err := LoadLibrary(dir, m.decoder)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.library = lib
+	m.mu.Unlock()
+	return nil
+}
+
+// Library retorna a biblioteca carregada pelo LoadLibrary
+func (m *Manager) Library() *Library {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.library
+}
+
+// Clock retorna o relógio do manager, para os timers de quem usa as sessões
+func (m *Manager) Clock() Clock {
+	return m.clock
+}
+
+// NewURLTrack é o NewURLTrack do pacote com o decoder e o relógio do manager
+func (m *Manager) NewURLTrack(rawURL, title string) (*Track, error) {
+	track, err := NewURLTrack(rawURL, title, m.decoder)
+	if err != nil {
+		return nil, err
+	}
+	track.Source.(*HTTPSource).Clock = m.clock
+	return track, nil
+}
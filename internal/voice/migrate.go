@@ -0,0 +1,116 @@
+package voice
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleServerUpdate trata o evento de mudança de servidor de voz: a conexão é
+// refeita no endpoint novo em segundo plano e o playback continua do mesmo frame
+func (m *Manager) HandleServerUpdate(v *discordgo.VoiceServerUpdate) {
+	sess := m.GetSession(v.GuildID)
+	if sess == nil {
+		return
+	}
+
+	m.log.Info("Recebido Voice Server Update (Migração)", "guild_id", v.GuildID, "endpoint", v.Endpoint)
+	id, since, stop, err := sess.beginMigration()
+	if err != nil {
+		// Conectando ou reconectando (o Dial em andamento já usa o servidor novo) ou fechada
+		m.log.Info("Migração ignorada", "guild_id", v.GuildID, "error", err)
+		return
+	}
+	go m.migrate(sess, id, since, stop, v.Endpoint)
+}
+
+// beginMigration entra em Migrating e retorna o número da migração e a Generation
+// que a conexão precisa passar. Um Voice Server Update no meio de outra migração a
+// substitui: a antiga é parada e perde o direito de sair de Migrating, e a nova
+// espera uma conexão aberta depois da que a antiga pode ter aberto.
+func (sess *Session) beginMigration() (int, uint64, <-chan struct{}, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	since := sess.connGen
+	if sess.state == StateMigrating {
+		since = max(since, sess.conn.Generation())
+	} else if err := sess.transitionLocked(StateMigrating); err != nil {
+		return 0, 0, nil, err
+	}
+	sess.stopMigrationLocked()
+	sess.migration++
+	sess.migrationStop = make(chan struct{})
+	return sess.migration, since, sess.migrationStop, nil
+}
+
+// endMigration sai de Migrating se id ainda for a migração em andamento, guardando
+// a Generation da conexão migrada (0 se a migração falhou). A conexão nova começa
+// sem Speaking, então o player volta a anunciar ao retomar.
+func (sess *Session) endMigration(id int, gen uint64) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if id != sess.migration || sess.state != StateMigrating {
+		return false
+	}
+	sess.migrationStop = nil
+	sess.connGen = max(sess.connGen, gen)
+	sess.speaking = false
+	return sess.transitionLocked(sess.phase) == nil
+}
+
+// stopMigrationLocked avisa a migração em andamento que ela não vale mais.
+// Deve ser chamado com sess.mu travado.
+func (sess *Session) stopMigrationLocked() {
+	if sess.migrationStop != nil {
+		close(sess.migrationStop)
+		sess.migrationStop = nil
+	}
+}
+
+// migrate espera a conexão ficar pronta no servidor novo (no máximo Timeouts.Migration).
+// O player não lê a fonte enquanto a sessão está em Migrating, então a faixa continua
+// do frame em que parou. Se a migração falhar, a política de reconexão assume.
+func (m *Manager) migrate(sess *Session, id int, since uint64, stop <-chan struct{}, endpoint string) {
+	log := m.log.With("guild_id", sess.GuildID, "endpoint", endpoint)
+
+	vc := sess.GetConnection()
+	gen, err := awaitMigration(m.clock, vc, since, m.timeouts.Migration, stop)
+	if err == nil {
+		// Aquece o UDP no servidor novo antes do player voltar a enviar
+		if err := sendSilence(m.clock, vc); err != nil {
+			log.Warn("Erro enviando silêncio na migração", "error", err)
+		}
+	}
+
+	// Substituída por outra migração (ou sessão fechada): ela cuida do estado
+	if !sess.endMigration(id, gen) {
+		return
+	}
+	if err != nil {
+		log.Warn("Migração falhou, tentando reconectar", "error", err)
+		sess.requestReconnect()
+		return
+	}
+	log.Info("Migração de servidor de voz concluída")
+}
+
+// awaitMigration espera a conexão ficar pronta com uma Generation maior que since e
+// retorna essa Generation. Quem troca de servidor é o discordgo: ele trata o mesmo
+// Voice Server Update no gateway (onVoiceServerUpdate), fecha o websocket e o UDP e
+// abre de novo com o endpoint e o token do evento. Esse handler roda em paralelo com
+// o nosso, então a conexão pode estar no servidor antigo, fechada ou já pronta no
+// novo quando olhamos; a Generation distingue os casos sem depender de ver o Ready cair.
+func awaitMigration(clock Clock, vc VoiceTransport, since uint64, timeout time.Duration, cancel <-chan struct{}) (uint64, error) {
+	var gen uint64
+	migrated := func() bool {
+		gen = vc.Generation()
+		return gen > since && vc.Ready()
+	}
+	if !waitUntil(clock, clock.After(timeout), cancel, migrated) {
+		return 0, errors.New("conexão não ficou pronta no servidor novo")
+	}
+	return gen, nil
+}
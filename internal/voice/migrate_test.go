@@ -0,0 +1,176 @@
+package voice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// serverUpdate entrega o Voice Server Update ao manager e, como o discordgo, derruba
+// a conexão para abrir de novo no servidor novo
+func serverUpdate(sess *Session, endpoint string) {
+	conn := sess.GetConnection().(*fakeTransport)
+	sess.owner.HandleServerUpdate(&discordgo.VoiceServerUpdate{GuildID: sess.GuildID, Endpoint: endpoint, Token: "token-" + endpoint})
+	conn.serverUpdate()
+}
+
+func TestMigrationResumesAtSamePosition(t *testing.T) {
+	sess, dialer := joinFake(t, WithLogger(quietLogger))
+	conn := dialer.transport(0)
+	conn.setMigrate(300 * time.Millisecond)
+
+	sess.PlayLoop(toneItem(30*time.Second, 0), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(conn.audio()) > 10 })
+
+	serverUpdate(sess, "novo.discord.media")
+	if !sess.IsMigrating() {
+		t.Fatal("sessão não entrou em migração")
+	}
+	time.Sleep(40 * time.Millisecond) // Frame em andamento
+	pos, sent := sess.Position(), len(conn.audio())
+
+	// No meio da migração o player não lê a fonte nem envia frames
+	time.Sleep(200 * time.Millisecond)
+	if !sess.IsMigrating() {
+		t.Fatal("migração terminou antes da conexão ficar pronta")
+	}
+	if got := sess.Position(); got != pos {
+		t.Errorf("posição durante a migração = %s, esperado o frame em que parou (%s)", got, pos)
+	}
+	if got := len(conn.audio()); got != sent {
+		t.Errorf("%d frames enviados durante a migração", got-sent)
+	}
+
+	eventually(t, 2*time.Second, "fim da migração", func() bool { return sess.State() == StatePlaying })
+	if got := sess.Position(); got < pos || got > pos+100*time.Millisecond {
+		t.Errorf("posição depois da migração = %s, esperado continuar de %s", got, pos)
+	}
+	eventually(t, time.Second, "áudio no servidor novo", func() bool { return len(conn.audio()) > sent })
+	if dialer.dials() != 1 {
+		t.Errorf("dials = %d: a migração não deveria abrir uma conexão nova", dialer.dials())
+	}
+}
+
+// Uma migração substituída por outra não pode encerrar a nova, nem pelo timeout dela
+func TestMigrationStaleTimeout(t *testing.T) {
+	sess, dialer := joinFake(t, WithLogger(quietLogger), WithTimeouts(Timeouts{
+		Ready:      time.Second,
+		Migration:  time.Second,
+		LeaveDelay: time.Second,
+	}))
+	conn := dialer.transport(0)
+	sess.PlayLoop(toneItem(30*time.Second, 0), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(conn.audio()) > 0 })
+
+	// A primeira nunca fica pronta; a segunda chega antes do timeout da primeira
+	conn.setMigrate(-1)
+	serverUpdate(sess, "primeiro")
+	time.Sleep(500 * time.Millisecond)
+	conn.setMigrate(850 * time.Millisecond)
+	serverUpdate(sess, "segundo")
+
+	// O timeout da primeira (1s) passou, a segunda ainda não ficou pronta
+	time.Sleep(700 * time.Millisecond)
+	if got := sess.State(); got != StateMigrating {
+		t.Fatalf("estado = %s: a migração antiga encerrou a nova", got)
+	}
+
+	eventually(t, 2*time.Second, "fim da segunda migração", func() bool { return sess.State() == StatePlaying })
+	if dialer.dials() != 1 || sess.IsReconnecting() {
+		t.Errorf("dials = %d, reconectando = %v: a migração antiga acionou a reconexão", dialer.dials(), sess.IsReconnecting())
+	}
+}
+
+// O discordgo trata o Voice Server Update em outra goroutine: se o nosso handler
+// rodar antes, a conexão ainda está pronta no servidor antigo e não pode encerrar a migração
+func TestMigrationWaitsForDrop(t *testing.T) {
+	sess, dialer := joinFake(t, WithLogger(quietLogger))
+	conn := dialer.transport(0)
+	conn.setMigrate(100 * time.Millisecond)
+	sess.PlayLoop(toneItem(30*time.Second, 0), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(conn.audio()) > 0 })
+
+	sess.owner.HandleServerUpdate(&discordgo.VoiceServerUpdate{GuildID: sess.GuildID, Endpoint: "novo"})
+	time.Sleep(300 * time.Millisecond)
+	if got := sess.State(); got != StateMigrating {
+		t.Fatalf("estado = %s: a migração terminou na conexão antiga", got)
+	}
+
+	conn.serverUpdate()
+	eventually(t, time.Second, "fim da migração", func() bool { return sess.State() == StatePlaying })
+	if dialer.dials() != 1 {
+		t.Errorf("dials = %d: a migração não deveria abrir uma conexão nova", dialer.dials())
+	}
+}
+
+// Com o handshake instantâneo ninguém vê o Ready cair: a migração termina pela
+// Generation da conexão, seja o nosso handler ou o do discordgo o primeiro a rodar
+func TestMigrationInstantHandshake(t *testing.T) {
+	tests := []struct {
+		name           string
+		discordgoFirst bool
+	}{
+		{"handler primeiro", false},
+		{"discordgo primeiro", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess, dialer := joinFake(t, WithLogger(quietLogger), WithTimeouts(Timeouts{
+				Ready:      time.Second,
+				Migration:  time.Second,
+				LeaveDelay: time.Second,
+			}))
+			conn := dialer.transport(0)
+			conn.setMigrate(0)
+			sess.PlayLoop(toneItem(30*time.Second, 0), 0)
+			eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(conn.audio()) > 0 })
+
+			if tt.discordgoFirst {
+				conn.serverUpdate()
+				sess.owner.HandleServerUpdate(&discordgo.VoiceServerUpdate{GuildID: sess.GuildID, Endpoint: "novo"})
+			} else {
+				serverUpdate(sess, "novo")
+			}
+
+			// Bem antes do timeout da migração (1s)
+			eventually(t, 500*time.Millisecond, "fim da migração", func() bool { return sess.State() == StatePlaying })
+			if dialer.dials() != 1 {
+				t.Errorf("dials = %d: a migração não deveria abrir uma conexão nova", dialer.dials())
+			}
+		})
+	}
+}
+
+func TestMigrationFailureReconnects(t *testing.T) {
+	sess, dialer := joinFake(t, WithLogger(quietLogger), WithRetry(fastRetry), WithTimeouts(Timeouts{
+		Ready:      time.Second,
+		Migration:  200 * time.Millisecond,
+		LeaveDelay: time.Second,
+	}))
+	conn := dialer.transport(0)
+	sess.PlayLoop(toneItem(30*time.Second, 0), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(conn.audio()) > 0 })
+
+	// O servidor novo nunca completa o handshake
+	conn.setMigrate(-1)
+	serverUpdate(sess, "novo")
+
+	eventually(t, 3*time.Second, "áudio na conexão da reconexão", func() bool {
+		next := dialer.transport(1)
+		return next != nil && len(next.audio()) > 0
+	})
+	if !conn.isDisconnected() {
+		t.Error("conexão antiga não foi fechada")
+	}
+	eventually(t, time.Second, "fim da reconexão", func() bool { return sess.State() == StatePlaying })
+}
+
+// A Generation do discordTransport lê um campo interno do discordgo: sem UDP aberto
+// ela fica em 0, e se o campo mudar de nome numa atualização o teste quebra aqui
+func TestDiscordTransportGeneration(t *testing.T) {
+	tr := &discordTransport{vc: &discordgo.VoiceConnection{}}
+	if gen := tr.Generation(); gen != 0 {
+		t.Errorf("Generation sem UDP = %d, esperado 0", gen)
+	}
+}
@@ -0,0 +1,281 @@
+package voice
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/hajimehoshi/go-mp3"
+	"layeh.com/gopus"
+)
+
+// nativeDecoder decodifica MP3, WAV e Ogg/Opus em Go, sem processos externos
+type nativeDecoder struct{}
+
+func (nativeDecoder) Name() string { return "nativo" }
+
+func (nativeDecoder) Decode(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(64)
+
+	var (
+		pcm   io.Reader
+		rate  int
+		chans int
+		err   error
+	)
+	switch detectFormat(header) {
+	case formatWAV:
+		pcm, rate, chans, err = decodeWAV(br)
+	case formatMP3:
+		src := &mp3Source{r: br}
+		var dec *mp3.Decoder
+		dec, err = mp3.NewDecoder(src)
+		if err == nil {
+			// go-mp3 sempre entrega s16le estéreo
+			pcm, rate, chans = &mp3Reader{Decoder: dec, src: src}, dec.SampleRate(), 2
+		}
+	case formatOggOpus:
+		pcm, err = decodeOggOpus(br)
+		rate, chans = frameRate, channels
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(newPCMConverter(pcm, rate, chans)), nil
+}
+
+// mp3Source detecta MP3 cortados. O go-mp3 lê cada parte do frame com io.ReadFull e
+// trata um frame incompleto como fim do arquivo; uma leitura curta seguida do fim
+// do arquivo indica que o io.ReadFull ainda esperava bytes, ou seja, o frame foi cortado.
+type mp3Source struct {
+	r         io.Reader
+	short     bool // A última leitura com dados veio menor que o pedido
+	truncated bool
+}
+
+func (s *mp3Source) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err == io.EOF && (s.short || (n > 0 && n < len(p))) {
+		s.truncated = true
+	}
+	if n > 0 {
+		s.short = n < len(p)
+	}
+	return n, err
+}
+
+// mp3Reader troca o io.EOF do go-mp3 por erro quando o arquivo terminou no meio de um frame
+type mp3Reader struct {
+	*mp3.Decoder
+	src *mp3Source
+}
+
+func (r *mp3Reader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF && r.src.truncated {
+		return n, fmt.Errorf("MP3 truncado no meio de um frame: %w", io.ErrUnexpectedEOF)
+	}
+	return n, err
+}
+
+// decodeWAV lê o cabeçalho RIFF e devolve os dados convertidos para s16le
+func decodeWAV(r io.Reader) (io.Reader, int, int, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, 0, 0, fmt.Errorf("cabeçalho WAV inválido: %w", err)
+	}
+
+	var (
+		format, chans, bits uint16
+		rate                uint32
+		gotFmt              bool
+	)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, 0, 0, fmt.Errorf("WAV sem chunk de dados: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil || size < 16 {
+				return nil, 0, 0, fmt.Errorf("chunk fmt inválido")
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			chans = binary.LittleEndian.Uint16(body[2:4])
+			rate = binary.LittleEndian.Uint32(body[4:8])
+			bits = binary.LittleEndian.Uint16(body[14:16])
+			// WAVE_FORMAT_EXTENSIBLE: o formato real está no SubFormat
+			if format == 0xFFFE && size >= 26 {
+				format = binary.LittleEndian.Uint16(body[24:26])
+			}
+			gotFmt = true
+		case "data":
+			if !gotFmt {
+				return nil, 0, 0, fmt.Errorf("chunk data antes do fmt")
+			}
+			if chans == 0 || rate == 0 {
+				return nil, 0, 0, fmt.Errorf("WAV com parâmetros inválidos")
+			}
+			data := io.LimitReader(r, size)
+			pcm, err := wavToS16(data, format, bits)
+			return pcm, int(rate), int(chans), err
+		default:
+			// Chunks têm tamanho par (padding)
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, 0, 0, fmt.Errorf("WAV truncado: %w", err)
+			}
+		}
+	}
+}
+
+// wavToS16 converte as amostras do WAV (PCM 8/16/24/32 bits ou float 32) para s16le
+func wavToS16(r io.Reader, format, bits uint16) (io.Reader, error) {
+	const (
+		wavePCM   = 1
+		waveFloat = 3
+	)
+
+	switch {
+	case format == wavePCM && bits == 16:
+		return r, nil
+	case format == wavePCM && (bits == 8 || bits == 24 || bits == 32):
+	case format == waveFloat && bits == 32:
+	default:
+		return nil, fmt.Errorf("%w: WAV formato %d com %d bits", ErrUnsupportedFormat, format, bits)
+	}
+
+	width := int(bits / 8)
+	return &sampleMapper{r: r, width: width, convert: func(b []byte) int16 {
+		switch {
+		case format == waveFloat:
+			v := float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+			return toSample(max(-1, min(v, 1)))
+		case bits == 8:
+			// WAV 8 bits é sem sinal
+			return int16(int(b[0])-128) << 8
+		case bits == 24:
+			return int16(uint16(b[1]) | uint16(b[2])<<8)
+		default:
+			return int16(binary.LittleEndian.Uint32(b) >> 16)
+		}
+	}}, nil
+}
+
+// sampleMapper converte amostras de largura arbitrária para s16le
+type sampleMapper struct {
+	r       io.Reader
+	width   int
+	convert func([]byte) int16
+	buf     []byte
+}
+
+func (m *sampleMapper) Read(p []byte) (int, error) {
+	n := len(p) / 2
+	if n == 0 {
+		return 0, nil
+	}
+	if cap(m.buf) < n*m.width {
+		m.buf = make([]byte, n*m.width)
+	}
+	in := m.buf[:n*m.width]
+
+	read, err := io.ReadFull(m.r, in)
+	samples := read / m.width
+	for i := 0; i < samples; i++ {
+		binary.LittleEndian.PutUint16(p[i*2:], uint16(m.convert(in[i*m.width:(i+1)*m.width])))
+	}
+	if samples > 0 {
+		return samples * 2, nil
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return 0, err
+}
+
+// decodeOggOpus decodifica um Ogg/Opus com a libopus (já sai em 48kHz estéreo)
+func decodeOggOpus(r io.Reader) (io.Reader, error) {
+	ogg := newOggReader(r)
+	head, err := readOpusHead(ogg)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := gopus.NewDecoder(frameRate, channels)
+	if err != nil {
+		return nil, fmt.Errorf("falha decoder opus: %v", err)
+	}
+
+	return &opusPCMReader{ogg: ogg, decoder: decoder, skip: int(head.PreSkip) * channels}, nil
+}
+
+// opusHead são os campos do OpusHead que interessam à decodificação
+type opusHead struct {
+	Channels int
+	PreSkip  uint16
+}
+
+// readOpusHead lê os dois pacotes de cabeçalho (OpusHead e OpusTags) do stream
+func readOpusHead(ogg *oggReader) (opusHead, error) {
+	packet, err := ogg.NextPacket()
+	if err != nil || len(packet) < 19 || !bytes.HasPrefix(packet, []byte("OpusHead")) {
+		return opusHead{}, fmt.Errorf("%w: stream Ogg sem OpusHead", ErrUnsupportedFormat)
+	}
+	head := opusHead{
+		Channels: int(packet[9]),
+		PreSkip:  binary.LittleEndian.Uint16(packet[10:12]),
+	}
+
+	if tags, err := ogg.NextPacket(); err != nil || !bytes.HasPrefix(tags, []byte("OpusTags")) {
+		return opusHead{}, fmt.Errorf("stream Ogg/Opus sem OpusTags")
+	}
+	return head, nil
+}
+
+// opusPCMReader decodifica os pacotes Opus sob demanda
+type opusPCMReader struct {
+	ogg     *oggReader
+	decoder *gopus.Decoder
+	skip    int // Amostras de pre-skip ainda a descartar
+	pending []byte
+}
+
+func (o *opusPCMReader) Read(p []byte) (int, error) {
+	for len(o.pending) == 0 {
+		packet, err := o.ogg.NextPacket()
+		if err != nil {
+			return 0, err
+		}
+
+		// 5760 amostras = maior frame Opus possível (120ms)
+		pcm, err := o.decoder.Decode(packet, 5760, false)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao decodificar pacote opus: %v", err)
+		}
+		if o.skip > 0 {
+			drop := min(o.skip, len(pcm))
+			pcm = pcm[drop:]
+			o.skip -= drop
+		}
+
+		o.pending = make([]byte, len(pcm)*2)
+		for i, v := range pcm {
+			binary.LittleEndian.PutUint16(o.pending[i*2:], uint16(v))
+		}
+	}
+
+	n := copy(p, o.pending)
+	o.pending = o.pending[n:]
+	return n, nil
+}
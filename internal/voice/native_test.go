@@ -0,0 +1,370 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"testing"
+
+	"layeh.com/gopus"
+)
+
+// wavChunk é um chunk RIFF qualquer (tamanhos ímpares ganham o byte de padding)
+type wavChunk struct {
+	id   string
+	body []byte
+}
+
+// wavFile monta um WAV com os chunks na ordem pedida
+func wavFile(chunks ...wavChunk) []byte {
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+	for _, c := range chunks {
+		body.WriteString(c.id)
+		binary.Write(&body, binary.LittleEndian, uint32(len(c.body)))
+		body.Write(c.body)
+		if len(c.body)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+// fmtChunk é o chunk fmt; com extensible o formato vai no SubFormat
+func fmtChunk(format uint16, chans, rate, bits int, extensible bool) wavChunk {
+	var b bytes.Buffer
+	tag := format
+	if extensible {
+		tag = 0xFFFE
+	}
+	align := chans * bits / 8
+	for _, v := range []any{tag, uint16(chans), uint32(rate), uint32(rate * align), uint16(align), uint16(bits)} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	if extensible {
+		// cbSize, bits válidos, máscara de canais e o GUID do SubFormat (formato nos 2 primeiros bytes)
+		for _, v := range []any{uint16(22), uint16(bits), uint32(3), format} {
+			binary.Write(&b, binary.LittleEndian, v)
+		}
+		b.Write([]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71})
+	}
+	return wavChunk{"fmt ", b.Bytes()}
+}
+
+func le16(samples ...int16) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(s))
+	}
+	return out
+}
+
+// decodeAll passa o arquivo pelo decoder nativo e devolve as amostras (48kHz estéreo)
+func decodeAll(t *testing.T, data []byte) ([]int16, error) {
+	t.Helper()
+	raw, err := decodeReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]int16, len(raw)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(raw[i*2:]))
+	}
+	return samples, nil
+}
+
+// decodeReader lê todo o PCM que o decoder nativo produz a partir de r
+func decodeReader(r io.Reader) ([]byte, error) {
+	stream, err := nativeDecoder{}.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return io.ReadAll(stream)
+}
+
+func TestDecodeWAV(t *testing.T) {
+	f32 := func(vs ...float32) []byte {
+		var b bytes.Buffer
+		binary.Write(&b, binary.LittleEndian, vs)
+		return b.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		file []byte
+		want []int16 // Amostras esperadas (48kHz estéreo)
+	}{
+		{
+			name: "16 bits estéreo",
+			file: wavFile(fmtChunk(1, 2, 48000, 16, false), wavChunk{"data", le16(100, -100, 200, -200)}),
+			want: []int16{100, -100, 200, -200},
+		},
+		{
+			name: "8 bits mono",
+			file: wavFile(fmtChunk(1, 1, 48000, 8, false), wavChunk{"data", []byte{128, 255, 0}}),
+			want: []int16{0, 0, 127 << 8, 127 << 8, -128 << 8, -128 << 8},
+		},
+		{
+			name: "24 bits",
+			file: wavFile(fmtChunk(1, 2, 48000, 24, false), wavChunk{"data", []byte{0xFF, 0x34, 0x12, 0x00, 0x00, 0x80}}),
+			want: []int16{0x1234, math.MinInt16},
+		},
+		{
+			name: "32 bits",
+			file: wavFile(fmtChunk(1, 2, 48000, 32, false), wavChunk{"data", []byte{0xFF, 0xFF, 0x34, 0x12, 0x00, 0x00, 0xFF, 0x7F}}),
+			want: []int16{0x1234, math.MaxInt16},
+		},
+		{
+			name: "float 32",
+			file: wavFile(fmtChunk(3, 2, 48000, 32, false), wavChunk{"data", f32(0, 2)}),
+			want: []int16{0, math.MaxInt16},
+		},
+		{
+			name: "WAVE_FORMAT_EXTENSIBLE",
+			file: wavFile(fmtChunk(1, 2, 48000, 16, true), wavChunk{"data", le16(7, -7)}),
+			want: []int16{7, -7},
+		},
+		{
+			name: "chunks de tamanho ímpar antes dos dados",
+			file: wavFile(wavChunk{"LIST", []byte("abc")}, fmtChunk(1, 2, 48000, 16, false), wavChunk{"junk", []byte{1}}, wavChunk{"data", le16(5, 6)}),
+			want: []int16{5, 6},
+		},
+		{
+			name: "24kHz mono",
+			file: wavFile(fmtChunk(1, 1, 24000, 16, false), wavChunk{"data", le16(0, 100, 200)}),
+			// Cada amostra vira duas, com a do meio interpolada; o fim repete a última
+			want: []int16{0, 0, 50, 50, 100, 100, 150, 150, 200, 200, 200, 200},
+		},
+	}
+	for _, tt := range tests {
+		got, err := decodeAll(t, tt.file)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: amostras = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeWAVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file []byte
+		want error // nil = só precisa falhar
+	}{
+		{"formato ADPCM", wavFile(fmtChunk(2, 2, 48000, 4, false), wavChunk{"data", []byte{1, 2}}), ErrUnsupportedFormat},
+		{"float 64", wavFile(fmtChunk(3, 2, 48000, 64, false), wavChunk{"data", make([]byte, 16)}), ErrUnsupportedFormat},
+		{"dados antes do fmt", wavFile(wavChunk{"data", le16(1, 2)}, fmtChunk(1, 2, 48000, 16, false)), nil},
+		{"sem dados", wavFile(fmtChunk(1, 2, 48000, 16, false)), nil},
+		{"taxa zero", wavFile(fmtChunk(1, 2, 0, 16, false), wavChunk{"data", le16(1, 2)}), nil},
+		{"fmt curto", wavFile(wavChunk{"fmt ", []byte{1, 0, 2, 0}}, wavChunk{"data", le16(1, 2)}), nil},
+	}
+	for _, tt := range tests {
+		_, err := decodeAll(t, tt.file)
+		if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Errorf("%s: err = %v, esperado %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// mp3Silence monta frames MPEG-1 Layer III de silêncio (side info zerada), com tag ID3 opcional
+func mp3Silence(frames int, id3 bool) []byte {
+	var b bytes.Buffer
+	if id3 {
+		b.Write([]byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 0})
+	}
+	// 128kbps, 44.1kHz, estéreo, sem CRC: 144 * 128000 / 44100 = 417 bytes por frame
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	for range frames {
+		b.Write(frame)
+	}
+	return b.Bytes()
+}
+
+func TestDecodeMP3(t *testing.T) {
+	for _, id3 := range []bool{false, true} {
+		file := mp3Silence(20, id3)
+		if got := detectFormat(file[:64]); got != formatMP3 {
+			t.Fatalf("id3=%v: formato detectado = %d, esperado MP3", id3, got)
+		}
+		samples, err := decodeAll(t, file)
+		if err != nil {
+			t.Fatalf("id3=%v: %v", id3, err)
+		}
+
+		// 1152 amostras por frame a 44.1kHz, reamostradas para 48kHz (o decoder pode descartar o primeiro frame)
+		frames := len(samples) / 2
+		if lo, hi := 19*1152*48000/44100, 20*1152*48000/44100+2; frames < lo || frames > hi {
+			t.Errorf("id3=%v: %d frames de saída, esperado entre %d e %d", id3, frames, lo, hi)
+		}
+		for i, s := range samples {
+			if s != 0 {
+				t.Fatalf("id3=%v: amostra %d = %d, esperado silêncio", id3, i, s)
+			}
+		}
+	}
+}
+
+// oggPage monta uma página Ogg com os segmentos dados (o CRC não é conferido pelo leitor)
+func oggPage(serial uint32, segments []byte, data []byte) []byte {
+	header := make([]byte, 27)
+	copy(header, "OggS")
+	binary.LittleEndian.PutUint32(header[14:], serial)
+	header[26] = byte(len(segments))
+	return append(append(header, segments...), data...)
+}
+
+// lacing divide o tamanho de um pacote nos segmentos do Ogg (255 = continua)
+func lacing(size int) []byte {
+	segs := bytes.Repeat([]byte{255}, size/255)
+	return append(segs, byte(size%255))
+}
+
+// oggOpus monta um Ogg/Opus com uma página por pacote, mais uma página de outro
+// stream lógico no meio e o último pacote dividido entre duas páginas
+func oggOpus(t *testing.T, preSkip uint16, packets [][]byte) []byte {
+	t.Helper()
+	head := append([]byte("OpusHead"), 1, 2, 0, 0, 0x80, 0xBB, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint16(head[10:], preSkip)
+	tags := append([]byte("OpusTags"), 0, 0, 0, 0, 0, 0, 0, 0)
+
+	var b bytes.Buffer
+	b.Write(oggPage(1, lacing(len(head)), head))
+	b.Write(oggPage(1, lacing(len(tags)), tags))
+	for n, p := range packets {
+		if n == 1 {
+			b.Write(oggPage(2, lacing(3), []byte{1, 2, 3}))
+		}
+		if n == len(packets)-1 && len(p) > 255 {
+			// Primeira página só com segmentos de 255: o pacote continua na seguinte
+			b.Write(oggPage(1, []byte{255}, p[:255]))
+			b.Write(oggPage(1, lacing(len(p)-255), p[255:]))
+			continue
+		}
+		b.Write(oggPage(1, lacing(len(p)), p))
+	}
+	return b.Bytes()
+}
+
+// opusPackets codifica n frames de um tom. O bitrate é alto para que os pacotes
+// passem de 255 bytes (mais de um segmento Ogg).
+func opusPackets(t *testing.T, n int) [][]byte {
+	t.Helper()
+	enc, err := gopus.NewEncoder(frameRate, channels, gopus.Audio)
+	if err != nil {
+		t.Fatalf("encoder: %v", err)
+	}
+	enc.SetBitrate(256000)
+
+	tone, _ := NewToneSource(440, 0).Open(0)
+	var packets [][]byte
+	for range n {
+		f, _ := tone.ReadFrame()
+		p, err := enc.Encode(f.PCM, frameSize, maxBytes)
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		packets = append(packets, p)
+	}
+	return packets
+}
+
+func TestDecodeOggOpus(t *testing.T) {
+	packets := opusPackets(t, 5)
+	if len(packets[4]) <= 255 {
+		t.Fatalf("pacote de %d bytes, o teste precisa de um com mais de 255", len(packets[4]))
+	}
+
+	tests := []struct {
+		preSkip uint16
+		want    int // Amostras por canal
+	}{
+		{0, 5 * frameSize},
+		{312, 5*frameSize - 312},
+	}
+	for _, tt := range tests {
+		file := oggOpus(t, tt.preSkip, packets)
+		if got := detectFormat(file[:64]); got != formatOggOpus {
+			t.Fatalf("formato detectado = %d, esperado Ogg/Opus", got)
+		}
+		samples, err := decodeAll(t, file)
+		if err != nil {
+			t.Fatalf("pre-skip %d: %v", tt.preSkip, err)
+		}
+		if got := len(samples) / 2; got != tt.want {
+			t.Errorf("pre-skip %d: %d amostras por canal, esperado %d", tt.preSkip, got, tt.want)
+		}
+	}
+
+	// Sem OpusTags o stream é recusado
+	head := append([]byte("OpusHead"), 1, 2, 0, 0, 0x80, 0xBB, 0, 0, 0, 0, 0)
+	broken := append(oggPage(1, lacing(len(head)), head), oggPage(1, lacing(2), packets[0][:2])...)
+	if _, err := decodeAll(t, broken); err == nil {
+		t.Error("Ogg/Opus sem OpusTags deveria falhar")
+	}
+}
+
+// failingReader entrega os bytes e depois falha com err no lugar do io.EOF
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if len(f.data) == 0 {
+		return 0, f.err
+	}
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+// Arquivos cortados e erros de leitura no meio do stream falham em vez de virar
+// uma faixa mais curta
+func TestDecodeTruncated(t *testing.T) {
+	errRead := errors.New("conexão caiu")
+	mp3 := mp3Silence(20, false)
+	ogg := oggOpus(t, 0, opusPackets(t, 5))
+	wav := func(rate int) []byte {
+		return wavFile(fmtChunk(1, 2, rate, 16, false), wavChunk{"data", make([]byte, rate/10*4)})
+	}
+
+	tests := []struct {
+		name   string
+		reader io.Reader
+		want   error
+	}{
+		{"MP3 cortado no meio de um frame", bytes.NewReader(mp3[:len(mp3)-100]), io.ErrUnexpectedEOF},
+		{"MP3 com erro de leitura", &failingReader{mp3[:len(mp3)/2], errRead}, errRead},
+		{"Ogg cortado no meio do pacote", bytes.NewReader(ogg[:len(ogg)-50]), io.ErrUnexpectedEOF},
+		{"Ogg cortado no cabeçalho da página", bytes.NewReader(ogg[:bytes.LastIndex(ogg, []byte("OggS"))+10]), io.ErrUnexpectedEOF},
+		{"Ogg com erro de leitura", &failingReader{ogg[:len(ogg)/2], errRead}, errRead},
+		{"WAV 44.1kHz com erro de leitura", &failingReader{wav(44100)[:10000], errRead}, errRead},
+		{"WAV 48kHz com erro de leitura", &failingReader{wav(48000)[:10000], errRead}, errRead},
+	}
+	for _, tt := range tests {
+		_, err := decodeReader(tt.reader)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, esperado %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Os mesmos arquivos inteiros decodificam sem erro
+	for name, file := range map[string][]byte{"MP3": mp3, "Ogg": ogg, "WAV": wav(44100)} {
+		if _, err := decodeReader(bytes.NewReader(file)); err != nil {
+			t.Errorf("%s inteiro: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package voice
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// oggReader extrai os pacotes do primeiro stream lógico de um arquivo Ogg
+type oggReader struct {
+	r       *bufio.Reader
+	serial  uint32
+	started bool
+	pending []byte   // Pacote incompleto que continua na próxima página
+	packets [][]byte // Pacotes completos ainda não entregues
+}
+
+func newOggReader(r io.Reader) *oggReader {
+	return &oggReader{r: bufio.NewReader(r)}
+}
+
+// NextPacket retorna o próximo pacote completo (io.EOF no fim do stream)
+func (o *oggReader) NextPacket() ([]byte, error) {
+	for len(o.packets) == 0 {
+		if err := o.readPage(); err != nil {
+			return nil, err
+		}
+	}
+
+	packet := o.packets[0]
+	o.packets = o.packets[1:]
+	return packet, nil
+}
+
+// readPage lê uma página e separa seus segmentos em pacotes. Só o fim do arquivo
+// entre duas páginas, sem pacote pela metade, é io.EOF; um corte no meio da página é erro.
+func (o *oggReader) readPage() error {
+	var header [27]byte
+	if _, err := io.ReadFull(o.r, header[:]); err != nil {
+		if err != io.EOF {
+			return truncatedOgg(err)
+		}
+		if len(o.pending) > 0 {
+			return fmt.Errorf("Ogg truncado: último pacote incompleto: %w", io.ErrUnexpectedEOF)
+		}
+		return io.EOF
+	}
+	if string(header[0:4]) != "OggS" {
+		return fmt.Errorf("página Ogg inválida")
+	}
+
+	serial := binary.LittleEndian.Uint32(header[14:18])
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(o.r, segments); err != nil {
+		return truncatedOgg(err)
+	}
+
+	size := 0
+	for _, seg := range segments {
+		size += int(seg)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(o.r, data); err != nil {
+		return truncatedOgg(err)
+	}
+
+	// Ignora páginas de outros streams lógicos (multiplexados)
+	if !o.started {
+		o.serial = serial
+		o.started = true
+	} else if serial != o.serial {
+		return nil
+	}
+
+	// Um pacote termina no primeiro segmento com menos de 255 bytes
+	off := 0
+	for _, seg := range segments {
+		o.pending = append(o.pending, data[off:off+int(seg)]...)
+		off += int(seg)
+		if seg < 255 {
+			o.packets = append(o.packets, o.pending)
+			o.pending = nil
+		}
+	}
+	return nil
+}
+
+// truncatedOgg converte o fim do arquivo no meio de uma página em erro. O
+// io.ErrUnexpectedEOF vai embrulhado para não ser confundido com o último frame
+// parcial de um stream PCM.
+func truncatedOgg(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("Ogg truncado no meio de uma página: %w", io.ErrUnexpectedEOF)
+	}
+	return err
+}
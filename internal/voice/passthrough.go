@@ -0,0 +1,122 @@
+package voice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// newOggPassthrough extrai os pacotes de um Ogg/Opus para envio direto ao Discord.
+// Falha se algum pacote não tiver exatamente 20ms (o tamanho de frame do Discord).
+func newOggPassthrough(data []byte) (*Audio, error) {
+	ogg := newOggReader(bytes.NewReader(data))
+	head, err := readOpusHead(ogg)
+	if err != nil {
+		return nil, err
+	}
+	if head.Channels > 2 {
+		return nil, fmt.Errorf("%w: Ogg/Opus com %d canais", ErrUnsupportedFormat, head.Channels)
+	}
+
+	var frames [][]byte
+	for {
+		packet, err := ogg.NextPacket()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkFrameDuration(packet); err != nil {
+			return nil, err
+		}
+		frames = append(frames, packet)
+	}
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("Ogg/Opus sem pacotes de áudio")
+	}
+	return &Audio{Frames: frames, Passthrough: true}, nil
+}
+
+// NewDCAAudio lê um arquivo DCA (DCA1 com metadados JSON ou DCA0 legado).
+// Os frames já são Opus de 20ms e vão direto para o Discord.
+func NewDCAAudio(data []byte) (*Audio, error) {
+	r := bytes.NewReader(data)
+
+	// DCA1: "DCA1" + tamanho (int32) + metadados JSON, depois os frames
+	if bytes.HasPrefix(data, []byte("DCA1")) {
+		var metaSize int32
+		r.Seek(4, io.SeekStart)
+		if err := binary.Read(r, binary.LittleEndian, &metaSize); err != nil || metaSize < 0 {
+			return nil, fmt.Errorf("cabeçalho DCA1 inválido")
+		}
+		if _, err := r.Seek(int64(metaSize), io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("cabeçalho DCA1 inválido: %w", err)
+		}
+	}
+
+	// Cada frame: tamanho (int16) + pacote Opus
+	var frames [][]byte
+	for {
+		var size int16
+		if err := binary.Read(r, binary.LittleEndian, &size); err == io.EOF {
+			break
+		} else if err != nil || size <= 0 {
+			return nil, fmt.Errorf("frame DCA inválido na posição %d", len(frames))
+		}
+
+		packet := make([]byte, size)
+		if _, err := io.ReadFull(r, packet); err != nil {
+			return nil, fmt.Errorf("DCA truncado no frame %d", len(frames))
+		}
+		if err := checkFrameDuration(packet); err != nil {
+			return nil, err
+		}
+		frames = append(frames, packet)
+	}
+
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("DCA sem frames")
+	}
+	return &Audio{Frames: frames, Passthrough: true}, nil
+}
+
+// checkFrameDuration confere pelo TOC que o pacote Opus tem 20ms
+func checkFrameDuration(packet []byte) error {
+	if len(packet) == 0 {
+		return fmt.Errorf("pacote opus vazio")
+	}
+
+	// Quantidade de frames pelo código do TOC (gopus.CountFrames trata o retorno positivo como erro)
+	var count int
+	switch packet[0] & 0x3 {
+	case 0:
+		count = 1
+	case 1, 2:
+		count = 2
+	default:
+		if len(packet) < 2 {
+			return fmt.Errorf("pacote opus inválido: código 3 sem contagem de frames")
+		}
+		count = int(packet[1] & 0x3F)
+	}
+
+	// Duração de cada frame em décimos de milissegundo, pela configuração do TOC (RFC 6716, 3.1)
+	config := int(packet[0] >> 3)
+	var frameTenths int
+	switch {
+	case config < 12: // SILK: 10, 20, 40, 60ms
+		frameTenths = []int{100, 200, 400, 600}[config%4]
+	case config < 16: // Hybrid: 10, 20ms
+		frameTenths = []int{100, 200}[config%2]
+	default: // CELT: 2.5, 5, 10, 20ms
+		frameTenths = []int{25, 50, 100, 200}[config%4]
+	}
+
+	if count*frameTenths != 200 {
+		return fmt.Errorf("%w: pacote opus de %.1fms (esperado 20ms)", ErrUnsupportedFormat, float64(count*frameTenths)/10)
+	}
+	return nil
+}
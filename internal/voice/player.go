@@ -0,0 +1,618 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// PlayLoop toca o item em loop (Loops <= 0 = infinito), interrompendo o que estiver tocando.
+// A fila é preservada e continua quando a faixa terminar. limit é o tempo máximo do
+// player novo (0 = sem limite), ver SetTimeLimit.
+func (sess *Session) PlayLoop(item *QueueItem, limit time.Duration) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.startLocked(item, limit)
+}
+
+// startLocked cancela o player atual e inicia um novo a partir do item. O limite de
+// tempo é gravado junto com a troca de geração: o defer do player anterior não o apaga.
+// Deve ser chamado com sess.mu travado.
+func (sess *Session) startLocked(first *QueueItem, limit time.Duration) {
+	// Sessão fechada por um Leave concorrente não volta a tocar
+	if err := sess.setPhaseLocked(StatePlaying); err != nil {
+		sess.owner.log.Warn("Player não iniciado", "guild_id", sess.GuildID, "error", err)
+		return
+	}
+	if sess.cancel != nil {
+		sess.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sess.cancel = cancel
+	sess.reconnectErr = nil // Desistência de um player anterior não vale para o novo
+	sess.generation++
+	sess.timeLimit, sess.deadline = max(limit, 0), time.Time{}
+
+	prev := sess.done
+	sess.done = make(chan struct{})
+	go sess.run(ctx, sess.generation, first, prev, sess.done)
+}
+
+// run é a goroutine do player: prepara a conexão e toca os itens da fila até ela esvaziar.
+// prev é o done do player substituído (nil se não havia) e done é fechado ao terminar.
+func (sess *Session) run(ctx context.Context, generation int, item *QueueItem, prev, done chan struct{}) {
+	m := sess.owner
+	log := m.log.With("guild_id", sess.GuildID)
+	defer func() {
+		// Libera o estado do player se ninguém iniciou outro no lugar
+		sess.mu.Lock()
+		if generation == sess.generation {
+			sess.setPhaseLocked(StateReady) // Falha apenas se a sessão já foi fechada
+			sess.current = nil
+			sess.timeLimit, sess.deadline = 0, time.Time{}
+		}
+		sess.mu.Unlock()
+		close(done)
+
+		// Cancelado significa substituição (nova música) ou leave (o manager já tratou).
+		// Em ambos os casos não saímos do canal.
+		if ctx.Err() == context.Canceled {
+			log.Info("Playback cancelado (substituição)")
+			return
+		}
+
+		log.Info("Playback finalizado, saindo do canal...", "delay", m.timeouts.LeaveDelay)
+		select {
+		case <-ctx.Done():
+			// Algo começou a tocar durante a espera
+			return
+		case <-m.clock.After(m.timeouts.LeaveDelay):
+		}
+		m.Leave(sess.GuildID)
+	}()
+
+	// O player substituído ainda pode estar no fade-out: espera ele liberar a conexão
+	if prev != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-prev:
+		}
+	}
+
+	// 1. Aguarda conexão estar PRONTA (Ready) com Timeout
+	// O handshake de voz (v4/v5) pode demorar devido ao IP Discovery e negociação de criptografia.
+	timeout := m.clock.After(m.timeouts.Ready)
+	ticker := m.clock.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	ready := false
+	for !ready {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+			log.Warn("Timeout aguardando Voice Connection Ready INICIAL", "ready", transportReady(sess.GetConnection()))
+			return
+		case <-ticker.C():
+			if transportReady(sess.GetConnection()) {
+				ready = true
+			}
+		}
+	}
+
+	// Aguarda estabilização da conexão UDP (evita panic no opusSender)
+	sleep(m.clock, 250*time.Millisecond)
+
+	// 2. Define falando como TRUE
+	sess.setSpeaking(true)
+	defer sess.setSpeaking(false)
+
+	// 3. Envia frames de silêncio para "aquecer" a conexão UDP e o SSRC
+	if err := sendSilence(m.clock, sess.GetConnection()); err != nil {
+		log.Warn("Erro enviando silêncio", "error", err)
+	}
+
+	// 4. Toca o item atual e depois segue a fila, sempre pela mesma saída
+	out := newOutput(m.clock)
+	defer out.Close()
+
+	var incoming *deck // Próxima faixa já aberta por um crossfade
+	defer func() {
+		if incoming != nil {
+			incoming.Close()
+		}
+	}()
+
+	for item != nil {
+		d := incoming
+		incoming = nil
+		// A fila pode ter mudado durante o crossfade: só aproveita o deck se for o mesmo item
+		if d != nil && d.item != item {
+			d.Close()
+			d = nil
+		}
+		if d == nil {
+			var err error
+			if d, err = openDeck(item, int(item.Start/frameDuration), sess.owner.targetLUFS); err != nil {
+				log.Warn("Erro na fonte de áudio, pulando faixa", "track_id", item.Track.ID, "error", err)
+				item = sess.next(generation, nil)
+				continue
+			}
+		}
+
+		var ok bool
+		if incoming, ok = sess.playItem(ctx, d, out, log.With("track_id", item.Track.ID)); !ok {
+			return
+		}
+		// Faixas com erro na fonte não voltam para a fila nos modos de repetição
+		finished := item
+		if d.failed {
+			finished = nil
+		}
+		item = sess.next(generation, finished)
+	}
+}
+
+// Pause pausa o playback mantendo a posição na faixa
+func (sess *Session) Pause() bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.phase != StatePlaying {
+		return false
+	}
+	return sess.setPhaseLocked(StatePaused) == nil
+}
+
+// Resume retoma o playback do mesmo frame em que foi pausado
+func (sess *Session) Resume() bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.phase != StatePaused {
+		return false
+	}
+	return sess.setPhaseLocked(StatePlaying) == nil
+}
+
+func (sess *Session) IsPaused() bool {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.phase == StatePaused
+}
+
+// SetVolume altera o ganho da sessão em tempo real (100 = original, máximo MaxVolume)
+func (sess *Session) SetVolume(volume int) {
+	volume = max(0, min(volume, MaxVolume))
+
+	sess.mu.Lock()
+	sess.volume = volume
+	sess.mu.Unlock()
+}
+
+func (sess *Session) Volume() int {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.volume
+}
+
+// Seek pula para a posição na faixa atual. Vale para o loop em andamento.
+func (sess *Session) Seek(pos time.Duration) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.current == nil {
+		return fmt.Errorf("nada tocando")
+	}
+	if !sess.current.Track.Source.Seekable() {
+		return ErrNotSeekable
+	}
+	if pos < 0 || (sess.current.Track.Duration > 0 && pos >= sess.current.Track.Duration) {
+		return ErrSeekOutOfRange
+	}
+
+	sess.seekTo = int(pos / frameDuration)
+	return nil
+}
+
+// Position retorna a posição atual na faixa tocando
+func (sess *Session) Position() time.Duration {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return time.Duration(sess.position) * frameDuration
+}
+
+// Progress é um retrato do playback para exibição (painel, /fila)
+type Progress struct {
+	Track     *Track
+	Position  time.Duration
+	Loop      int // Loop atual, começando em 1
+	Loops     int // Total de loops (<= 0 = infinito)
+	Mode      LoopMode
+	Volume    int
+	Paused    bool
+	Queued    int           // Itens aguardando na fila
+	Effects   []string      // Efeitos ligados pelo /efeito
+	TimeLimit time.Duration // Duração do domínio com tempo limitado (0 = sem limite)
+	TimeLeft  time.Duration // Quanto falta do domínio (conta a partir do primeiro frame)
+}
+
+// Progress retorna o estado atual do playback (false se nada estiver tocando)
+func (sess *Session) Progress() (Progress, bool) {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+
+	if sess.current == nil {
+		return Progress{}, false
+	}
+
+	return Progress{
+		Track:     sess.current.Track,
+		Position:  time.Duration(sess.position) * frameDuration,
+		Loop:      sess.loop + 1,
+		Loops:     sess.loops,
+		Mode:      sess.loopMode,
+		Volume:    sess.volume,
+		Paused:    sess.phase == StatePaused,
+		Queued:    len(sess.queue),
+		Effects:   sess.effects,
+		TimeLimit: sess.timeLimit,
+		TimeLeft:  sess.timeLeftLocked(),
+	}, true
+}
+
+// takeSeek consome um /seek pendente (-1 se não houver)
+func (sess *Session) takeSeek() int {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	target := sess.seekTo
+	sess.seekTo = -1
+	return target
+}
+
+// setPosition publica o frame atual para Position()
+func (sess *Session) setPosition(pos int) {
+	sess.mu.Lock()
+	sess.position = pos
+	sess.mu.Unlock()
+}
+
+// setSpeaking atualiza o estado Speaking apenas quando ele muda
+func (sess *Session) setSpeaking(speaking bool) {
+	sess.mu.RLock()
+	conn := sess.conn
+	changed := sess.speaking != speaking
+	sess.mu.RUnlock()
+
+	// Verifica se conexão ainda existe antes de falar
+	if !changed || conn == nil || !conn.Ready() {
+		return
+	}
+	// Speaking manda um pacote pelo websocket de voz: não segura o lock da sessão
+	conn.Speaking(speaking)
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	// Se a conexão foi trocada no meio tempo, o estado gravado não vale para a nova
+	if sess.conn == conn {
+		sess.speaking = speaking
+	}
+}
+
+// playItem toca o deck pelo número de loops pedido. O fim de um loop emenda no
+// começo do próximo dentro do mesmo tick (gapless), e no último loop a próxima
+// faixa da fila pode entrar em crossfade; nesse caso o deck dela é retornado já
+// tocando. Retorna false quando o player inteiro deve parar (erro, cancelamento ou Lazy Exit).
+//
+// Frames Opus sem efeitos e sem ganho são enviados diretamente; nos demais casos
+// efeitos e ganho são aplicados sobre o PCM e o frame é codificado na hora. O ganho
+// é a normalização de loudness da faixa vezes o volume, lido a cada frame, então o
+// /volume tem efeito no frame seguinte. Por cima dele entram as rampas de fade:
+// fade-in ao começar, retomar ou depois de um pulo, e fade-out ao pausar, pular ou parar.
+func (sess *Session) playItem(ctx context.Context, d *deck, out *output, log *slog.Logger) (*deck, bool) {
+	defer d.Close()
+
+	// Contexto próprio da faixa: o /pular cancela só ele
+	itemCtx, skip := context.WithCancel(ctx)
+	defer skip()
+
+	item := d.item
+	sess.mu.Lock()
+	sess.current = item
+	sess.skip = skip
+	sess.volume = item.Volume
+	sess.seekTo = -1
+	sess.loop = 0
+	sess.loops = item.Loops
+	sess.position = d.pos()
+	if sess.loopMode == LoopShuffle {
+		// Sorteia já a próxima faixa: o /fila e o crossfade enxergam a mesma que vai tocar
+		sess.shuffleNextLocked()
+	}
+	sess.mu.Unlock()
+	out.enc.sourceChanged()
+
+	var (
+		incoming        *deck // Próxima faixa, durante o crossfade
+		fadeFrames      int   // Duração do crossfade em andamento
+		crossfadeFailed bool
+		played          bool // Algum frame foi lido da fonte
+	)
+	dropIncoming := func() {
+		if incoming != nil {
+			incoming.Close()
+			incoming = nil
+		}
+	}
+
+	for {
+		if out.due {
+			out.due = false
+		} else {
+			select {
+			case <-itemCtx.Done():
+				// Parar ou pular termina com fade-out em vez de cortar o frame
+				dropIncoming()
+				out.fadeOut(sess, d)
+				// Faixa pulada segue para a próxima; player cancelado encerra
+				if ctx.Err() != nil {
+					return nil, false
+				}
+				log.Info("Faixa pulada")
+				return nil, true
+			case <-out.ticker.C():
+			}
+		}
+
+		// 0. Verifica se está migrando
+		// Se estiver, pausamos o envio e aguardamos (continue o loop sem erro) sem ler a
+		// fonte: a faixa continua do mesmo frame, com fade-in, no servidor novo
+		if sess.IsMigrating() {
+			out.fader.cut()
+			continue
+		}
+
+		// Aplica /seek pendente (funciona também em pausa)
+		if target := sess.takeSeek(); target >= 0 {
+			dropIncoming()
+			if err := d.seek(target); err != nil {
+				log.Warn("Erro na fonte de áudio, pulando faixa", "error", err)
+				d.failed = true
+				return nil, true
+			}
+			out.enc.sourceChanged()
+			sess.setPosition(d.pos())
+		}
+
+		// Pausado: depois do fade-out não envia frames nem avança a posição. O prazo
+		// corre em pausa e pode acabar nela.
+		paused := sess.IsPaused()
+		if paused && out.fader.silent() {
+			if sess.deadlinePassed() {
+				log.Info("Tempo do domínio esgotado: saindo do canal.")
+				dropIncoming()
+				return nil, false
+			}
+			sess.setSpeaking(false)
+			continue
+		}
+
+		// 1. Verifica estado da conexão
+		vc, err := out.connection(sess)
+		if err != nil {
+			log.Error("Erro tocando áudio", "error", err, "loop", sess.currentLoop())
+			dropIncoming()
+			return nil, false
+		}
+		if vc == nil {
+			// Sem conexão não há o que cortar: o áudio volta com fade-in
+			out.fader.cut()
+			continue
+		}
+
+		// Tempo do domínio esgotado: encerra o player e sai do canal, como no Lazy Exit.
+		// Só é conferido com a conexão pronta: durante migrações e reconexões o fim não
+		// é anunciado (o prazo continua correndo e acaba no primeiro frame depois delas).
+		deadlineGain, expired := sess.deadlineFade()
+		if expired {
+			log.Info("Tempo do domínio esgotado: saindo do canal.")
+			dropIncoming()
+			return nil, false
+		}
+
+		// Volta a falar após um resume
+		sess.setSpeaking(true)
+
+		// 2. Lê o próximo frame. No fim de um loop a decisão acontece dentro do
+		// mesmo tick, então o último frame emenda direto no primeiro.
+		frame, err := d.read(sess)
+		if err == io.EOF {
+			// Verifica Lazy Exit após terminar a música
+			if sess.IsDraining() {
+				log.Info("Lazy Exit ativado: saindo após término da música.")
+				dropIncoming()
+				return nil, false
+			}
+			if !sess.advanceLoop() {
+				// O tick atual fica para a próxima faixa. Uma fonte que não rendeu
+				// nenhum frame não volta para a fila (evita girar sem tocar nada).
+				d.failed = !played
+				out.due = true
+				return incoming, true
+			}
+
+			// O loop pode ter voltado a ser infinito depois do crossfade começar
+			dropIncoming()
+			crossfadeFailed = false
+			if err = d.seek(0); err == nil {
+				frame, err = d.read(sess)
+			}
+		}
+		if err == io.EOF {
+			// Fonte vazia: não há o que repetir
+			d.failed = true
+			out.due = true
+			return nil, true
+		}
+		if err != nil {
+			// Falha da fonte (URL fora do ar, arquivo inválido...) só afeta esta faixa
+			log.Warn("Erro na fonte de áudio, pulando faixa", "error", err)
+			d.failed = true
+			dropIncoming()
+			out.due = true
+			return nil, true
+		}
+		played = true
+		sess.setPosition(d.pos())
+		gain := d.normalization * float64(sess.Volume()) / 100 * deadlineGain
+
+		// 3. Crossfade com a próxima faixa da fila, mixado em PCM antes do encoder
+		if incoming == nil && !crossfadeFailed {
+			if next := sess.crossfadeCandidate(d); next != nil {
+				if incoming, err = openDeck(next, int(next.Start/frameDuration), sess.owner.targetLUFS); err != nil {
+					log.Warn("Erro abrindo a próxima faixa para o crossfade", "error", err)
+					incoming, crossfadeFailed = nil, true
+				} else {
+					fadeFrames = d.remaining() + 1
+					out.startCrossfade()
+					log.Info("Crossfade iniciado", "next_track_id", next.Track.ID, "frames", fadeFrames)
+				}
+			}
+		}
+		if incoming != nil {
+			if next, err := incoming.read(sess); err != nil {
+				// Próxima faixa curta demais (ou com erro): volta a tocar só esta
+				dropIncoming()
+			} else {
+				t := min(max(1-float64(d.remaining())/float64(fadeFrames), 0), 1)
+				nextGain := incoming.normalization * float64(incoming.item.Volume) / 100 * deadlineGain
+				if frame, err = out.crossfade(frame, gain, next, nextGain, t); err != nil {
+					continue
+				}
+				gain = 1
+			}
+		}
+
+		// 4. Rampa de fade-in (início, resume, pulo) ou fade-out (pausa) por cima do ganho
+		target := 1.0
+		if paused {
+			target = 0
+		}
+		from, to := out.fader.next(target, sess.Fade())
+
+		// 5. Pacote pronto ou codificado com o ganho aplicado
+		opusData, err := out.enc.encode(frame, gain*from, gain*to)
+		if err != nil {
+			continue
+		}
+		out.send(vc, opusData)
+	}
+}
+
+// crossfadeCandidate retorna o próximo item da fila quando o deck está no último
+// loop e dentro da janela de crossfade (nil caso contrário)
+func (sess *Session) crossfadeCandidate(d *deck) *QueueItem {
+	remaining := d.remaining()
+
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+
+	if sess.crossfade <= 0 || remaining < 0 || sess.phase == StateDraining || len(sess.queue) == 0 {
+		return nil
+	}
+	if !sess.finalLoopLocked(sess.loop) {
+		return nil
+	}
+	// O frame recém-lido conta: a sobreposição tem exatamente a duração do crossfade
+	if remaining >= int(sess.crossfade/frameDuration) {
+		return nil
+	}
+	return sess.queue[0]
+}
+
+// Duração do fade-out que antecede o fim de um domínio com tempo limitado
+const deadlineFadeOut = 3 * time.Second
+
+// SetTimeLimit troca o limite de tempo do player atual (0 = sem limite). A contagem
+// recomeça no próximo frame enviado com a conexão pronta, então o handshake de voz não
+// consome o tempo. Ao esgotar, o player termina com fade-out e sai do canal, mesmo com
+// loops ou fila restantes. O limite some quando o player termina, e um PlayLoop novo
+// o substitui pelo dele; sem player tocando a chamada não tem efeito duradouro.
+func (sess *Session) SetTimeLimit(d time.Duration) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.timeLimit, sess.deadline = d, time.Time{}
+}
+
+// timeLeftLocked retorna quanto falta do limite (o limite inteiro se a contagem não
+// começou). Deve ser chamado com sess.mu travado.
+func (sess *Session) timeLeftLocked() time.Duration {
+	if sess.deadline.IsZero() {
+		return sess.timeLimit
+	}
+	return max(sess.deadline.Sub(sess.owner.clock.Now()), 0)
+}
+
+// deadlinePassed indica se o prazo já começou a contar e terminou
+func (sess *Session) deadlinePassed() bool {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.timeLimit > 0 && !sess.deadline.IsZero() && sess.timeLeftLocked() <= 0
+}
+
+// deadlineFade retorna o ganho do fade-out do prazo (1 fora dele) e se o prazo já passou.
+// Chamado pelo player a cada frame com a conexão pronta, marca o fim do prazo no primeiro.
+func (sess *Session) deadlineFade() (float64, bool) {
+	sess.mu.Lock()
+	if sess.timeLimit <= 0 {
+		sess.mu.Unlock()
+		return 1, false
+	}
+	if sess.deadline.IsZero() {
+		sess.deadline = sess.owner.clock.Now().Add(sess.timeLimit)
+	}
+	left := sess.timeLeftLocked()
+	sess.mu.Unlock()
+
+	if left <= 0 {
+		return 0, true
+	}
+	return min(float64(left)/float64(deadlineFadeOut), 1), false
+}
+
+// SetCrossfade define a duração do crossfade entre faixas diferentes da fila (0 = desligado)
+func (sess *Session) SetCrossfade(d time.Duration) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.crossfade = max(d, 0)
+}
+
+// Tempo máximo esperando espaço no canal de envio para cada frame de silêncio
+const silenceSendTimeout = 100 * time.Millisecond
+
+// sendSilence envia alguns pacotes de silêncio para estabelecer a prioridade RTP
+func sendSilence(clock Clock, vc VoiceTransport) error {
+	// 5 frames de silêncio (20ms cada) = 100ms de pre-roll
+	for i := 0; i < 5; i++ {
+		silenceFrame := []byte{0xF8, 0xFF, 0xFE}
+
+		if !transportReady(vc) {
+			// Se não estiver pronto, apenas retorna erro sem crashar
+			return fmt.Errorf("voice connection not ready for silence")
+		}
+
+		// Canal cheio por muito tempo = UDP travado; não seguramos a goroutine nele
+		select {
+		case vc.OpusSend() <- silenceFrame:
+		case <-clock.After(silenceSendTimeout):
+			return fmt.Errorf("voice connection blocked sending silence")
+		}
+		sleep(clock, frameDuration)
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package voice
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// joinFake cria um manager isolado e entra no canal com uma conexão em memória
+func joinFake(t *testing.T, opts ...Option) (*Session, *fakeDialer) {
+	t.Helper()
+	dialer := &fakeDialer{}
+	m := NewManager(append([]Option{WithDialer(dialer.dial)}, opts...)...)
+	sess, err := m.Join("guild", "canal")
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	t.Cleanup(func() { m.Leave("guild") })
+	return sess, dialer
+}
+
+// toneItem é um item da fila com um tom da duração pedida
+func toneItem(length time.Duration, loops int) *QueueItem {
+	return &QueueItem{
+		Track:  &Track{ID: "tom", Title: "Tom", Duration: length, Source: NewToneSource(440, length)},
+		Loops:  loops,
+		Volume: 100,
+	}
+}
+
+func TestPlayerPacing(t *testing.T) {
+	sess, dialer := joinFake(t)
+	sess.PlayLoop(toneItem(time.Second, 1), 0)
+	eventually(t, 5*time.Second, "player terminar", func() bool { return !sess.IsPlaying() })
+
+	frames := dialer.transport(0).audio()
+	if len(frames) != 50 {
+		t.Fatalf("frames enviados = %d, esperado 50", len(frames))
+	}
+
+	span := frames[len(frames)-1].at.Sub(frames[0].at)
+	if span < 900*time.Millisecond || span > 1200*time.Millisecond {
+		t.Errorf("50 frames em %s, esperado ~980ms", span)
+	}
+	for i := 1; i < len(frames); i++ {
+		if gap := frames[i].at.Sub(frames[i-1].at); gap > 100*time.Millisecond {
+			t.Errorf("buraco de %s entre os frames %d e %d", gap, i-1, i)
+		}
+	}
+}
+
+func TestPlayerGaplessLoops(t *testing.T) {
+	sess, dialer := joinFake(t)
+	sess.PlayLoop(toneItem(400*time.Millisecond, 3), 0)
+	eventually(t, 5*time.Second, "player terminar", func() bool { return !sess.IsPlaying() })
+
+	if n := len(dialer.transport(0).audio()); n != 60 {
+		t.Errorf("frames enviados = %d, esperado 3 loops de 20", n)
+	}
+}
+
+func TestPlayerWaitsForReady(t *testing.T) {
+	sess, dialer := joinFake(t)
+	conn := dialer.transport(0)
+	conn.setReady(false)
+
+	sess.PlayLoop(toneItem(time.Second, 1), 0)
+	time.Sleep(500 * time.Millisecond)
+	if n := len(conn.audio()); n != 0 {
+		t.Fatalf("%d frames enviados antes da conexão ficar pronta", n)
+	}
+
+	conn.setReady(true)
+	eventually(t, 3*time.Second, "frames depois do Ready", func() bool { return len(conn.audio()) > 0 })
+}
+
+func TestPlayerDroppedFramesKeepPace(t *testing.T) {
+	sess, dialer := joinFake(t)
+	conn := dialer.transport(0)
+
+	sess.PlayLoop(toneItem(time.Second, 1), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(conn.audio()) > 0 })
+	conn.setState(fakeDropping)
+
+	start := time.Now()
+	eventually(t, 3*time.Second, "player terminar", func() bool { return !sess.IsPlaying() })
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("perda de pacotes atrasou o player: %s", elapsed)
+	}
+	if conn.droppedFrames() == 0 {
+		t.Error("nenhum frame chegou à conexão durante a perda")
+	}
+	if got := len(conn.audio()) + conn.droppedFrames(); got != 50 {
+		t.Errorf("recebidos + perdidos = %d, esperado 50", got)
+	}
+}
+
+func TestPlayerBlockedConnectionDoesNotStall(t *testing.T) {
+	sess, dialer := joinFake(t)
+	dialer.transport(0).setState(fakeBlocked)
+
+	start := time.Now()
+	sess.PlayLoop(toneItem(time.Second, 1), 0)
+	eventually(t, 4*time.Second, "player terminar com o canal travado", func() bool { return !sess.IsPlaying() })
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("canal travado segurou o player por %s", elapsed)
+	}
+}
+
+func TestSendSilenceBlocked(t *testing.T) {
+	conn := newFakeTransport("canal")
+	defer conn.Disconnect()
+	conn.setState(fakeBlocked)
+
+	start := time.Now()
+	if err := sendSilence(SystemClock, conn); err == nil {
+		t.Fatal("sendSilence com o canal travado deveria falhar")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("sendSilence travou por %s", elapsed)
+	}
+
+	conn.setReady(false)
+	if err := sendSilence(SystemClock, conn); err == nil {
+		t.Error("sendSilence sem conexão pronta deveria falhar")
+	}
+}
+
+// fastRetry reconecta logo, para os testes não esperarem os 5s do DefaultRetry
+var fastRetry = Retry{
+	ReconnectAfter: 200 * time.Millisecond,
+	BaseDelay:      20 * time.Millisecond,
+	MaxDelay:       50 * time.Millisecond,
+	MaxAttempts:    3,
+}
+
+func TestPlayerReconnect(t *testing.T) {
+	sess, dialer := joinFake(t, WithRetry(fastRetry))
+	item := toneItem(30*time.Second, 0)
+	sess.PlayLoop(item, 0)
+
+	old := dialer.transport(0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(old.audio()) > 10 })
+	old.setReady(false)
+	lost := sess.Position()
+
+	eventually(t, 3*time.Second, "reconexão", func() bool {
+		next := dialer.transport(1)
+		return next != nil && len(next.audio()) > 0
+	})
+
+	if dialer.dials() != 2 {
+		t.Errorf("dials = %d, esperado 2 (join + reconexão)", dialer.dials())
+	}
+	if !old.isDisconnected() {
+		t.Error("conexão antiga não foi fechada")
+	}
+	if sess.GetConnection() != dialer.transport(1) {
+		t.Error("sessão não trocou para a conexão nova")
+	}
+	if sess.Current() != item || !sess.IsPlaying() {
+		t.Error("o item tocando não sobreviveu à reconexão")
+	}
+	// Sem conexão o player não lê a fonte: a faixa continua de onde parou
+	if pos := sess.Position(); pos < lost || pos > lost+2*time.Second {
+		t.Errorf("posição depois da reconexão = %s, perdida em %s", pos, lost)
+	}
+}
+
+func TestPlayerLazyExit(t *testing.T) {
+	sess, dialer := joinFake(t)
+	conn := dialer.transport(0)
+
+	// Loop infinito: só o Lazy Exit encerra
+	sess.PlayLoop(toneItem(400*time.Millisecond, 0), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(conn.audio()) > 0 })
+	sess.Drain()
+
+	eventually(t, 5*time.Second, "sair do canal", func() bool { return sess.owner.GetSession(sess.GuildID) == nil })
+	if !conn.isDisconnected() {
+		t.Error("conexão não foi fechada no Lazy Exit")
+	}
+	// A música atual termina; no máximo o loop em que o Lazy Exit foi pedido
+	if n := len(conn.audio()); n < 20 || n > 40 {
+		t.Errorf("frames enviados = %d, esperado o fim da música em andamento", n)
+	}
+}
+
+// O limite do domínio conta a partir do primeiro frame, não do comando: a espera
+// pelo handshake de voz não consome o tempo
+// Com o relógio falso o prazo é conferido no tempo do manager, sem depender do
+// ritmo real dos frames
+func TestPlayerTimeLimitStartsWithPlayback(t *testing.T) {
+	clock := newFakeClock()
+	sess, dialer := joinFake(t, WithClock(clock), WithLogger(quietLogger))
+	conn := dialer.transport(0)
+	conn.setReady(false)
+
+	const limit = 500 * time.Millisecond
+	timeLeft := func() time.Duration {
+		sess.mu.RLock()
+		defer sess.mu.RUnlock()
+		return sess.timeLeftLocked()
+	}
+
+	// O handshake de voz demora 2s no relógio sem consumir o domínio
+	sess.PlayLoop(toneItem(400*time.Millisecond, 0), limit)
+	for range 100 {
+		clock.Advance(frameDuration)
+		time.Sleep(time.Millisecond)
+	}
+	if left := timeLeft(); left != limit {
+		t.Fatalf("restante = %s antes do primeiro frame, esperado os %s inteiros", left, limit)
+	}
+
+	conn.setReady(true)
+	clock.advanceUntil(t, "primeiros frames", func() bool { return len(conn.audio()) > 0 })
+	started := clock.Now()
+	left := timeLeft()
+	if left <= 0 || left > limit {
+		t.Fatalf("restante = %s no primeiro frame, esperado até %s", left, limit)
+	}
+
+	// Um frame antes do prazo o player continua; ele termina logo depois
+	clock.Advance(left - frameDuration)
+	if !sess.IsPlaying() {
+		t.Fatal("player terminou antes do prazo")
+	}
+	clock.advanceUntil(t, "fim do domínio", func() bool { return !sess.IsPlaying() })
+	if elapsed := clock.Now().Sub(started); elapsed > limit+5*frameDuration {
+		t.Errorf("player terminou %s depois do primeiro frame no relógio, esperado ~%s", elapsed, limit)
+	}
+}
+
+// O prazo não encerra o player no meio de uma migração: o fim só acontece com a
+// conexão de volta
+func TestPlayerTimeLimitWaitsForMigration(t *testing.T) {
+	sess, dialer := joinFake(t, WithLogger(quietLogger))
+	conn := dialer.transport(0)
+	conn.setMigrate(800 * time.Millisecond)
+
+	sess.PlayLoop(toneItem(30*time.Second, 0), 400*time.Millisecond)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(conn.audio()) > 0 })
+	serverUpdate(sess, "novo.discord.media")
+
+	time.Sleep(600 * time.Millisecond)
+	if !sess.IsMigrating() || !sess.IsPlaying() {
+		t.Fatalf("migrando = %v, tocando = %v: o prazo não deveria encerrar o player durante a migração", sess.IsMigrating(), sess.IsPlaying())
+	}
+	eventually(t, 3*time.Second, "fim do domínio depois da migração", func() bool { return !sess.IsPlaying() })
+}
+
+// passthroughItem é um item com um tom já codificado em Opus, como as faixas Ogg/DCA:
+// no crossfade os frames passam pelos decoders da saída
+func passthroughItem(t *testing.T, id string, frequency, amplitude float64, length time.Duration) *QueueItem {
+	t.Helper()
+	tone := &ToneSource{Frequency: frequency, Amplitude: amplitude, Length: length}
+	r, _ := tone.Open(0)
+	var pcm []int16
+	for {
+		f, err := r.ReadFrame()
+		if err != nil {
+			break
+		}
+		pcm = append(pcm, f.PCM...)
+	}
+	frames, err := encodeFrames(pcm)
+	if err != nil {
+		t.Fatalf("encodeFrames: %v", err)
+	}
+	audio := &Audio{Frames: frames, Passthrough: true}
+	return &QueueItem{Track: &Track{ID: id, Title: id, Duration: length, Source: audio}, Loops: 1, Volume: 100}
+}
+
+// rms decodifica os pacotes enviados em sequência e retorna o nível de cada frame (0..1)
+func rms(t *testing.T, frames []sentFrame) []float64 {
+	t.Helper()
+	var dec opusDecoder
+	levels := make([]float64, len(frames))
+	for n, fr := range frames {
+		pcm, err := dec.decode(fr.opus)
+		if err != nil {
+			t.Fatalf("frame %d: %v", n, err)
+		}
+		var sum float64
+		for _, s := range pcm {
+			v := float64(s) / 32768
+			sum += v * v
+		}
+		levels[n] = math.Sqrt(sum / float64(len(pcm)))
+	}
+	return levels
+}
+
+func TestPlayerCrossfade(t *testing.T) {
+	sess, dialer := joinFake(t)
+	sess.SetCrossfade(200 * time.Millisecond)
+
+	// Alto -> baixo -> alto: dois crossfades seguidos com os mesmos decoders da saída
+	sess.PlayLoop(passthroughItem(t, "a", 440, 0.5, time.Second), 0)
+	sess.Enqueue(passthroughItem(t, "b", 660, 0.05, time.Second))
+	sess.Enqueue(passthroughItem(t, "c", 440, 0.5, time.Second))
+	eventually(t, 10*time.Second, "player terminar", func() bool { return !sess.IsPlaying() })
+
+	// Cada crossfade sobrepõe 10 frames: 3 faixas de 50 frames viram 130
+	frames := dialer.transport(0).audio()
+	if len(frames) != 130 {
+		t.Fatalf("frames enviados = %d, esperado 130 (150 menos 2 crossfades de 10)", len(frames))
+	}
+
+	levels := rms(t, frames)
+	loud, quiet := 0.5/math.Sqrt2, 0.05/math.Sqrt2
+	near := func(got, want float64) bool { return math.Abs(got-want) < want*0.25 }
+	for _, n := range []int{20, 110} {
+		if !near(levels[n], loud) {
+			t.Errorf("frame %d: nível %.3f, esperado ~%.3f (faixa alta sozinha)", n, levels[n], loud)
+		}
+	}
+	if !near(levels[65], quiet) {
+		t.Errorf("frame 65: nível %.3f, esperado ~%.3f (faixa baixa sozinha)", levels[65], quiet)
+	}
+
+	// Na sobreposição o nível desce da faixa alta para a baixa e depois volta a subir.
+	// Os primeiros frames depois de cada troca entre passthrough e re-encode saem
+	// distorcidos no decoder do teste (o estado Opus é de outro encoder), então ficam de fora.
+	if !(levels[44] > levels[47] && levels[47] > levels[49] && levels[49] > quiet) {
+		t.Errorf("níveis do primeiro crossfade %.3f %.3f %.3f, esperado descendo até a faixa baixa", levels[44], levels[47], levels[49])
+	}
+	if !(levels[83] < levels[86] && levels[86] < levels[89] && levels[89] < loud*1.1) {
+		t.Errorf("níveis do segundo crossfade %.3f %.3f %.3f, esperado subindo até a faixa alta", levels[83], levels[86], levels[89])
+	}
+}
+
+func TestFader(t *testing.T) {
+	tests := []struct {
+		start, target float64
+		fade          time.Duration
+		from, to      float64
+	}{
+		// Fade 0 é corte seco: o primeiro frame já sai com volume cheio
+		{0, 1, 0, 1, 1},
+		{1, 0, 0, 0, 0},
+		{0, 1, 100 * time.Millisecond, 0, 0.2},
+		{1, 0, 100 * time.Millisecond, 1, 0.8},
+		{0.9, 1, 100 * time.Millisecond, 0.9, 1},
+	}
+	for _, tt := range tests {
+		f := fader{gain: tt.start}
+		from, to := f.next(tt.target, tt.fade)
+		if math.Abs(from-tt.from) > 1e-9 || math.Abs(to-tt.to) > 1e-9 {
+			t.Errorf("fader em %.1f para %.0f com fade %s: %.2f -> %.2f, esperado %.2f -> %.2f", tt.start, tt.target, tt.fade, from, to, tt.from, tt.to)
+		}
+	}
+}
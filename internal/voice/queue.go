@@ -0,0 +1,137 @@
+package voice
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidPosition é retornado quando a posição não existe na fila
+var ErrInvalidPosition = errors.New("posição inválida na fila")
+
+// QueueItem é uma faixa agendada com suas opções de playback
+type QueueItem struct {
+	Track       *Track
+	Loops       int // <= 0 = infinito
+	Volume      int
+	Start       time.Duration // Posição inicial, aplicada só no primeiro loop
+	RequestedBy string        // ID do usuário que pediu
+}
+
+// Enqueue adiciona o item ao fim da fila. Se nada estiver tocando, o playback
+// começa imediatamente. Retorna a posição na fila (0 = tocando agora).
+func (sess *Session) Enqueue(item *QueueItem) int {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if !sess.playingLocked() {
+		sess.startLocked(item, 0)
+		return 0
+	}
+
+	sess.queue = append(sess.queue, item)
+	return len(sess.queue)
+}
+
+// Skip interrompe a faixa atual e segue para a próxima da fila
+func (sess *Session) Skip() bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.current == nil || sess.skip == nil {
+		return false
+	}
+	// A próxima faixa começa tocando, mesmo que a atual esteja pausada
+	if sess.phase == StatePaused {
+		sess.setPhaseLocked(StatePlaying)
+	}
+	sess.skip()
+	return true
+}
+
+// Remove retira o item na posição index (base 0) da fila
+func (sess *Session) Remove(index int) (*QueueItem, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if index < 0 || index >= len(sess.queue) {
+		return nil, ErrInvalidPosition
+	}
+
+	item := sess.queue[index]
+	sess.queue = append(sess.queue[:index], sess.queue[index+1:]...)
+	return item, nil
+}
+
+// Move reposiciona o item de from para to (ambos base 0)
+func (sess *Session) Move(from, to int) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if from < 0 || from >= len(sess.queue) || to < 0 || to >= len(sess.queue) {
+		return ErrInvalidPosition
+	}
+
+	item := sess.queue[from]
+	sess.queue = append(sess.queue[:from], sess.queue[from+1:]...)
+	sess.queue = append(sess.queue[:to], append([]*QueueItem{item}, sess.queue[to:]...)...)
+	return nil
+}
+
+// Clear esvazia a fila (sem interromper a faixa atual) e retorna quantos itens foram removidos
+func (sess *Session) Clear() int {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	n := len(sess.queue)
+	sess.queue = nil
+	return n
+}
+
+// Queue retorna uma cópia dos itens aguardando na fila
+func (sess *Session) Queue() []*QueueItem {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+
+	return append([]*QueueItem(nil), sess.queue...)
+}
+
+// Current retorna o item tocando agora (nil se parado)
+func (sess *Session) Current() *QueueItem {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.current
+}
+
+// IsPlaying indica se o player está ativo (tocando, pausado ou no Lazy Exit)
+func (sess *Session) IsPlaying() bool {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.playingLocked()
+}
+
+func (sess *Session) playingLocked() bool {
+	return sess.phase == StatePlaying || sess.phase == StatePaused || sess.phase == StateDraining
+}
+
+// next retira o próximo item da fila. Nos modos de repetição da fila o item
+// terminado (finished, nil se falhou) volta para o fim antes. Se a fila estiver vazia,
+// marca o player como parado na mesma seção crítica para que um Enqueue concorrente
+// reinicie o playback.
+func (sess *Session) next(generation int, finished *QueueItem) *QueueItem {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if generation != sess.generation {
+		return nil
+	}
+	sess.requeueLocked(finished)
+	if len(sess.queue) == 0 {
+		sess.setPhaseLocked(StateReady)
+		sess.current = nil
+		return nil
+	}
+
+	item := sess.queue[0]
+	sess.queue = sess.queue[1:]
+	return item
+}
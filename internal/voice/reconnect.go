@@ -0,0 +1,320 @@
+package voice
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Retry é a política de recuperação de uma conexão de voz perdida durante o playback.
+// A reconexão roda em segundo plano: o player segue no ritmo dos frames enquanto ela tenta.
+type Retry struct {
+	ReconnectAfter time.Duration // Tempo sem conexão antes de começar a reconectar
+	BaseDelay      time.Duration // Espera antes da segunda tentativa; dobra a cada falha
+	MaxDelay       time.Duration // Teto da espera entre tentativas
+	Jitter         float64       // Fração aleatória (0-1) somada ou subtraída de cada espera
+	MaxAttempts    int           // Tentativas por queda antes de desistir (<= 0 = sem limite)
+	Settle         time.Duration // Espera depois de fechar a conexão antiga, antes de abrir a nova
+
+	// Disjuntor por servidor: depois de BreakerThreshold tentativas seguidas sem
+	// sucesso (somando quedas diferentes), novas tentativas são recusadas por
+	// BreakerCooldown. Depois dele uma tentativa passa; se falhar, abre de novo.
+	BreakerThreshold int // <= 0 = sem disjuntor
+	BreakerCooldown  time.Duration
+}
+
+// DefaultRetry tolera ~20s sem conexão e segura servidores instáveis para evitar Reconnect Storms
+var DefaultRetry = Retry{
+	ReconnectAfter:   5 * time.Second,
+	BaseDelay:        time.Second,
+	MaxDelay:         30 * time.Second,
+	Jitter:           0.2,
+	MaxAttempts:      5,
+	Settle:           250 * time.Millisecond,
+	BreakerThreshold: 10,
+	BreakerCooldown:  5 * time.Minute,
+}
+
+var (
+	// ErrReconnectGaveUp é retornado quando todas as tentativas de uma queda falharam
+	ErrReconnectGaveUp = errors.New("reconexão de voz esgotou as tentativas")
+	// ErrCircuitOpen é retornado quando o disjuntor do servidor recusou a tentativa
+	ErrCircuitOpen = errors.New("disjuntor de reconexão aberto")
+	// ErrSessionClosed é retornado quando a sessão saiu do canal no meio da operação
+	ErrSessionClosed = errors.New("sessão de voz encerrada")
+)
+
+// backoff é a espera antes da tentativa. A primeira é imediata: a conexão já
+// está perdida há ReconnectAfter.
+func (r Retry) backoff(attempt int) time.Duration {
+	if attempt <= 1 || r.BaseDelay <= 0 {
+		return 0
+	}
+
+	d := r.BaseDelay << min(attempt-2, 20)
+	if r.MaxDelay > 0 {
+		d = min(d, r.MaxDelay)
+	}
+	if r.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * r.Jitter * float64(d))
+	}
+	return max(d, 0)
+}
+
+// breaker é o disjuntor de reconexão de um servidor
+type breaker struct {
+	mu        sync.Mutex
+	failures  int // Tentativas seguidas sem sucesso
+	openUntil time.Time
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.openUntil)
+}
+
+func (b *breaker) record(now time.Time, ok bool, r Retry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ok {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if r.BreakerThreshold > 0 && b.failures >= r.BreakerThreshold {
+		b.openUntil = now.Add(r.BreakerCooldown)
+	}
+}
+
+// breaker retorna o disjuntor do servidor, criando na primeira queda
+func (m *Manager) breaker(guildID string) *breaker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.breakers[guildID]
+	if !ok {
+		b = &breaker{}
+		m.breakers[guildID] = b
+	}
+	return b
+}
+
+// ReconnectStep é o que aconteceu em uma tentativa de reconexão
+type ReconnectStep int
+
+const (
+	ReconnectStarted   ReconnectStep = iota // Tentativa começando (depois do backoff)
+	ReconnectSucceeded                      // Conexão nova pronta
+	ReconnectFailed                         // Tentativa falhou; haverá outra se sobrarem
+	ReconnectGaveUp                         // Tentativas da queda esgotadas
+	ReconnectRejected                       // Disjuntor aberto: a tentativa nem começou
+)
+
+var reconnectStepNames = map[ReconnectStep]string{
+	ReconnectStarted:   "tentando",
+	ReconnectSucceeded: "sucesso",
+	ReconnectFailed:    "falhou",
+	ReconnectGaveUp:    "desistiu",
+	ReconnectRejected:  "disjuntor aberto",
+}
+
+func (s ReconnectStep) String() string {
+	if name, ok := reconnectStepNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("ReconnectStep(%d)", int(s))
+}
+
+// ReconnectEvent descreve um passo de uma reconexão
+type ReconnectEvent struct {
+	GuildID string
+	Step    ReconnectStep
+	Attempt int           // Tentativa da queda atual, começando em 1
+	Delay   time.Duration // Backoff antes da tentativa
+	Err     error         // Motivo da falha (ReconnectFailed, ReconnectGaveUp, ReconnectRejected)
+	At      time.Time
+}
+
+// SubscribeReconnects assina os passos das reconexões de todas as sessões, como o Subscribe
+func (m *Manager) SubscribeReconnects(buffer int) (events <-chan ReconnectEvent, cancel func()) {
+	return m.reconnects.subscribe(buffer)
+}
+
+func (m *Manager) publishReconnect(ev ReconnectEvent) {
+	ev.At = m.clock.Now()
+	log := m.log.With("guild_id", ev.GuildID, "step", ev.Step, "attempt", ev.Attempt)
+	switch ev.Step {
+	case ReconnectStarted:
+		log.Info("Tentativa de reconexão de voz", "delay", ev.Delay)
+	case ReconnectSucceeded:
+		log.Info("Reconexão bem sucedida!")
+	case ReconnectFailed:
+		log.Warn("Falha na tentativa de reconexão", "error", ev.Err)
+	default:
+		log.Error("Reconexão de voz abandonada", "error", ev.Err)
+	}
+
+	if m.reconnects.publish(ev) > 0 {
+		m.log.Warn("Assinante de reconexões lento, evento descartado", "guild_id", ev.GuildID)
+	}
+}
+
+// Reconnect reabre a conexão de voz da sessão seguindo a política de Retry:
+// tentativas com backoff exponencial e jitter até MaxAttempts, respeitando o
+// disjuntor do servidor. Bloqueia até terminar; o player usa requestReconnect.
+func (m *Manager) Reconnect(guildID string) error {
+	sess := m.GetSession(guildID)
+	if sess == nil {
+		return fmt.Errorf("sessão não encontrada para reconexão")
+	}
+	return m.reconnect(sess)
+}
+
+func (m *Manager) reconnect(sess *Session) error {
+	if err := sess.begin(StateReconnecting); err != nil {
+		return fmt.Errorf("reconexão recusada: %w", err)
+	}
+	defer sess.end(StateReconnecting)
+
+	m.log.Info("Iniciando reconexão de voz...", "guild_id", sess.GuildID)
+	br := m.breaker(sess.GuildID)
+
+	attempt := 1
+	for ; m.retry.MaxAttempts <= 0 || attempt <= m.retry.MaxAttempts; attempt++ {
+		delay := m.retry.backoff(attempt)
+		select {
+		case <-sess.closed:
+			return ErrSessionClosed
+		case <-m.clock.After(delay):
+		}
+
+		if !br.allow(m.clock.Now()) {
+			m.publishReconnect(ReconnectEvent{GuildID: sess.GuildID, Step: ReconnectRejected, Attempt: attempt, Err: ErrCircuitOpen})
+			return ErrCircuitOpen
+		}
+
+		m.publishReconnect(ReconnectEvent{GuildID: sess.GuildID, Step: ReconnectStarted, Attempt: attempt, Delay: delay})
+		err := m.reconnectOnce(sess)
+		br.record(m.clock.Now(), err == nil, m.retry)
+		if err == nil {
+			m.publishReconnect(ReconnectEvent{GuildID: sess.GuildID, Step: ReconnectSucceeded, Attempt: attempt})
+			return nil
+		}
+		if errors.Is(err, ErrSessionClosed) {
+			return err
+		}
+		m.publishReconnect(ReconnectEvent{GuildID: sess.GuildID, Step: ReconnectFailed, Attempt: attempt, Err: err})
+	}
+
+	err := fmt.Errorf("%w (%d)", ErrReconnectGaveUp, attempt-1)
+	m.publishReconnect(ReconnectEvent{GuildID: sess.GuildID, Step: ReconnectGaveUp, Attempt: attempt - 1, Err: err})
+	return err
+}
+
+// reconnectOnce é uma tentativa: fecha a conexão antiga, abre outra e espera o
+// handshake dela. Uma conexão que não fica pronta conta como falha.
+func (m *Manager) reconnectOnce(sess *Session) error {
+	// Tenta desconectar a conexão antiga (pode falhar se já estiver fechada)
+	sess.mu.RLock()
+	old, dial, channelID := sess.conn, sess.dial, sess.channelID
+	sess.mu.RUnlock()
+	if old != nil {
+		old.Disconnect()
+		// Pequeno delay para limpeza
+		sleep(m.clock, m.retry.Settle)
+	}
+
+	// Reconecta usando o Dial armazenado (mute/deaf padrão)
+	vc, err := dial(sess.GuildID, channelID)
+	if err != nil {
+		return fmt.Errorf("falha ao reconectar: %w", err)
+	}
+	if !waitReady(m.clock, vc, m.timeouts.Ready, sess.closed) {
+		vc.Disconnect()
+		return errors.New("conexão nova não ficou pronta")
+	}
+
+	// Atualiza a referência da conexão na sessão PROTEGENDO A ESCRITA
+	// A fila, o item atual e os contadores/modo de loop vivem na Session, então sobrevivem à troca de conexão.
+	sess.mu.Lock()
+	if sess.state == StateClosed {
+		// Leave durante a reconexão: a conexão nova não tem dono
+		sess.mu.Unlock()
+		vc.Disconnect()
+		return ErrSessionClosed
+	}
+	sess.conn = vc
+	sess.connGen = vc.Generation()
+	sess.speaking = false // Conexão nova começa sem Speaking
+	sess.mu.Unlock()
+
+	// Envia silêncio para garantir handshake UDP
+	if err := sendSilence(m.clock, vc); err != nil {
+		m.log.Warn("Erro enviando silêncio na reconexão", "error", err)
+	}
+	return nil
+}
+
+// waitReady espera a conexão completar o handshake de voz (no máximo timeout)
+func waitReady(clock Clock, vc VoiceTransport, timeout time.Duration, cancel <-chan struct{}) bool {
+	return waitUntil(clock, clock.After(timeout), cancel, vc.Ready)
+}
+
+// waitUntil confere cond no ritmo do relógio até ela valer, o deadline chegar ou
+// cancel fechar. O discordgo não avisa quando a conexão muda de estado, só expõe o Ready.
+func waitUntil(clock Clock, deadline <-chan time.Time, cancel <-chan struct{}, cond func() bool) bool {
+	if cond() {
+		return true
+	}
+
+	ticker := clock.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cancel:
+			return false
+		case <-deadline:
+			return false
+		case <-ticker.C():
+			if cond() {
+				return true
+			}
+		}
+	}
+}
+
+// requestReconnect inicia a reconexão em segundo plano, se nenhuma estiver rodando.
+// O player não espera por ela: só descobre pelo reconnectFailure se ela desistir.
+func (sess *Session) requestReconnect() {
+	sess.mu.Lock()
+	if sess.reconnecting {
+		sess.mu.Unlock()
+		return
+	}
+	sess.reconnecting = true
+	sess.reconnectErr = nil
+	sess.mu.Unlock()
+
+	go func() {
+		err := sess.owner.reconnect(sess)
+
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+		sess.reconnecting = false
+		if errors.Is(err, ErrReconnectGaveUp) || errors.Is(err, ErrCircuitOpen) {
+			sess.reconnectErr = err
+		}
+	}()
+}
+
+// reconnectFailure retorna o motivo da última reconexão ter desistido (nil se não desistiu)
+func (sess *Session) reconnectFailure() error {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.reconnectErr
+}
@@ -0,0 +1,160 @@
+package voice
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	r := Retry{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	want := []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if got := r.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, esperado %s", i+1, got, w)
+		}
+	}
+
+	r.Jitter = 0.5
+	for range 100 {
+		if got := r.backoff(3); got < 100*time.Millisecond || got > 300*time.Millisecond {
+			t.Fatalf("backoff(3) com jitter 0.5 = %s, esperado entre 100ms e 300ms", got)
+		}
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	r := Retry{BreakerThreshold: 3, BreakerCooldown: time.Minute}
+	now := time.Now()
+	var b breaker
+
+	for range 2 {
+		b.record(now, false, r)
+	}
+	if !b.allow(now) {
+		t.Fatal("disjuntor abriu antes do limite")
+	}
+	b.record(now, false, r)
+	if b.allow(now) {
+		t.Fatal("disjuntor não abriu no limite")
+	}
+
+	// Depois do cooldown uma tentativa passa; se falhar, abre de novo
+	later := now.Add(time.Minute)
+	if !b.allow(later) {
+		t.Fatal("disjuntor não liberou depois do cooldown")
+	}
+	b.record(later, false, r)
+	if b.allow(later) {
+		t.Fatal("falha depois do cooldown deveria reabrir o disjuntor")
+	}
+
+	b.record(later, true, r)
+	if !b.allow(later) {
+		t.Error("sucesso deveria fechar o disjuntor")
+	}
+}
+
+// steps lê n eventos de reconexão
+func steps(t *testing.T, events <-chan ReconnectEvent, n int) []ReconnectEvent {
+	t.Helper()
+	var got []ReconnectEvent
+	for len(got) < n {
+		select {
+		case ev := <-events:
+			got = append(got, ev)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout esperando %d eventos de reconexão, chegaram %v", n, got)
+		}
+	}
+	return got
+}
+
+func checkSteps(t *testing.T, got []ReconnectEvent, want []ReconnectStep) {
+	t.Helper()
+	for i, ev := range got {
+		if ev.Step != want[i] {
+			t.Errorf("evento %d = %s (tentativa %d), esperado %s", i, ev.Step, ev.Attempt, want[i])
+		}
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	sess, dialer := joinFake(t, WithLogger(quietLogger), WithRetry(fastRetry))
+	events, cancel := sess.owner.SubscribeReconnects(16)
+	defer cancel()
+
+	sess.PlayLoop(toneItem(time.Second, 0), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(dialer.transport(0).audio()) > 0 })
+	dialer.setFailing(true)
+	dialer.transport(0).setReady(false)
+
+	got := steps(t, events, 7)
+	checkSteps(t, got, []ReconnectStep{
+		ReconnectStarted, ReconnectFailed,
+		ReconnectStarted, ReconnectFailed,
+		ReconnectStarted, ReconnectFailed,
+		ReconnectGaveUp,
+	})
+	if !errors.Is(got[6].Err, ErrReconnectGaveUp) {
+		t.Errorf("erro da desistência = %v", got[6].Err)
+	}
+	if got[2].Delay <= 0 || got[4].Delay <= got[2].Delay {
+		t.Errorf("esperas %s e %s, esperado backoff crescente", got[2].Delay, got[4].Delay)
+	}
+
+	// Com a política esgotada o player desiste e a sessão sai do canal
+	eventually(t, 3*time.Second, "player desistir", func() bool { return !sess.IsPlaying() })
+	if n := dialer.dials(); n != 4 {
+		t.Errorf("dials = %d, esperado 4 (join + 3 tentativas)", n)
+	}
+}
+
+func TestReconnectCircuitBreaker(t *testing.T) {
+	retry := fastRetry
+	retry.MaxAttempts = 5
+	retry.BreakerThreshold = 2
+	retry.BreakerCooldown = time.Hour
+	sess, dialer := joinFake(t, WithLogger(quietLogger), WithRetry(retry))
+	m := sess.owner
+	events, cancel := m.SubscribeReconnects(16)
+	defer cancel()
+
+	dialer.setFailing(true)
+	if err := m.Reconnect(sess.GuildID); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Reconnect = %v, esperado disjuntor aberto", err)
+	}
+	checkSteps(t, steps(t, events, 5), []ReconnectStep{
+		ReconnectStarted, ReconnectFailed,
+		ReconnectStarted, ReconnectFailed,
+		ReconnectRejected,
+	})
+
+	// O disjuntor é do servidor: a próxima queda é recusada sem discar
+	dialer.setFailing(false)
+	if err := m.Reconnect(sess.GuildID); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Reconnect com o disjuntor aberto = %v", err)
+	}
+	if n := dialer.dials(); n != 3 {
+		t.Errorf("dials = %d, esperado 3 (join + 2 tentativas)", n)
+	}
+	if sess.State() != StateReady {
+		t.Errorf("estado = %s depois da reconexão recusada, esperado pronta", sess.State())
+	}
+}
+
+// A reconexão roda fora do loop de frames: com o Dial travado o player continua
+// no ritmo e atende o Skip sem esperar por ela
+func TestReconnectDoesNotBlockPlayer(t *testing.T) {
+	sess, dialer := joinFake(t, WithLogger(quietLogger), WithRetry(fastRetry))
+	sess.PlayLoop(toneItem(30*time.Second, 0), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(dialer.transport(0).audio()) > 0 })
+
+	hold := dialer.setHold()
+	defer close(hold)
+	dialer.transport(0).setReady(false)
+	eventually(t, 2*time.Second, "reconexão começar", func() bool { return sess.IsReconnecting() })
+
+	sess.Skip()
+	eventually(t, time.Second, "player encerrar com o Dial travado", func() bool { return !sess.IsPlaying() })
+}
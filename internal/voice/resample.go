@@ -0,0 +1,111 @@
+package voice
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// pcmConverter converte PCM s16le de qualquer taxa/canais para 48kHz estéreo.
+// A reamostragem é linear, suficiente para músicas tocadas no Discord.
+// Só o io.EOF da entrada encerra o stream normalmente; qualquer outro erro
+// (download cortado, arquivo truncado) é repassado para quem lê.
+type pcmConverter struct {
+	r        *bufio.Reader
+	channels int
+	step     float64 // Frames de entrada por frame de saída
+	frac     float64 // Posição entre prev e next
+	prev     [2]int16
+	next     [2]int16
+	started  bool
+	eof      bool  // A entrada acabou: só falta interpolar até a última amostra
+	err      error // Erro que encerrou o stream, devolvido nas próximas leituras
+	frameBuf []byte
+}
+
+func newPCMConverter(r io.Reader, rate, chans int) io.Reader {
+	// Já está no formato do Discord: nada a fazer
+	if rate == frameRate && chans == channels {
+		return r
+	}
+	return &pcmConverter{
+		r:        bufio.NewReader(r),
+		channels: chans,
+		step:     float64(rate) / float64(frameRate),
+		frameBuf: make([]byte, chans*2),
+	}
+}
+
+// readFrame lê um frame de entrada e o mapeia para estéreo
+func (c *pcmConverter) readFrame() ([2]int16, error) {
+	if _, err := io.ReadFull(c.r, c.frameBuf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return [2]int16{}, fmt.Errorf("stream PCM terminou no meio de uma amostra: %w", err)
+		}
+		return [2]int16{}, err
+	}
+
+	left := int16(binary.LittleEndian.Uint16(c.frameBuf[0:2]))
+	if c.channels == 1 {
+		return [2]int16{left, left}, nil
+	}
+	// Mais de dois canais: usa os dois primeiros (L/R)
+	return [2]int16{left, int16(binary.LittleEndian.Uint16(c.frameBuf[2:4]))}, nil
+}
+
+func (c *pcmConverter) Read(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if !c.started {
+		c.started = true
+		var err error
+		if c.prev, err = c.readFrame(); err != nil {
+			c.err = err
+			return 0, err
+		}
+		if c.next, err = c.readFrame(); err != nil {
+			if err != io.EOF {
+				c.err = err
+				return 0, err
+			}
+			c.next = c.prev
+			c.eof = true
+		}
+	}
+
+	n := 0
+	for n+4 <= len(p) {
+		// Avança a janela de entrada até cobrir a posição atual
+		for c.frac >= 1 {
+			if c.eof {
+				if n == 0 {
+					c.err = io.EOF
+					return 0, io.EOF
+				}
+				return n, nil
+			}
+			c.prev = c.next
+			frame, err := c.readFrame()
+			if err != nil {
+				if err != io.EOF {
+					c.err = err
+					return n, err
+				}
+				c.eof = true
+				frame = c.prev
+			}
+			c.next = frame
+			c.frac--
+		}
+
+		for ch := 0; ch < 2; ch++ {
+			v := float64(c.prev[ch])*(1-c.frac) + float64(c.next[ch])*c.frac
+			binary.LittleEndian.PutUint16(p[n+ch*2:], uint16(int16(v)))
+		}
+		n += 4
+		c.frac += c.step
+	}
+	return n, nil
+}
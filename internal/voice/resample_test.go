@@ -0,0 +1,71 @@
+package voice
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+)
+
+// convert passa as amostras s16le pelo conversor e devolve a saída, lendo em
+// pedaços de tamanho chunk (ímpar de propósito) para exercitar leituras parciais
+func convert(t *testing.T, in []int16, rate, chans, chunk int) []int16 {
+	t.Helper()
+	r := newPCMConverter(bytes.NewReader(le16(in...)), rate, chans)
+
+	var raw []byte
+	buf := make([]byte, chunk)
+	for {
+		n, err := r.Read(buf)
+		raw = append(raw, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+	}
+	out := make([]int16, len(raw)/2)
+	for i := range out {
+		out[i] = int16(uint16(raw[i*2]) | uint16(raw[i*2+1])<<8)
+	}
+	return out
+}
+
+func TestPCMConverter(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    []int16
+		rate  int
+		chans int
+		want  []int16
+	}{
+		{"48kHz estéreo passa direto", []int16{1, 2, 3, 4}, 48000, 2, []int16{1, 2, 3, 4}},
+		{"48kHz mono duplica o canal", []int16{1, -2}, 48000, 1, []int16{1, 1, -2, -2}},
+		{"24kHz interpola no meio", []int16{0, 0, 100, -100}, 24000, 2, []int16{0, 0, 50, -50, 100, -100, 100, -100}},
+		{"96kHz pula amostras", []int16{0, 10, 20, 30, 40, 50}, 96000, 1, []int16{0, 0, 20, 20, 40, 40}},
+		{"16kHz em terços", []int16{0, 300}, 16000, 1, []int16{0, 0, 100, 100, 200, 200, 300, 300, 300, 300, 300, 300}},
+		{"6 canais usa L/R", []int16{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, 48000, 6, []int16{1, 2, 7, 8}},
+		{"uma amostra só", []int16{42}, 24000, 1, []int16{42, 42, 42, 42}},
+		{"vazio", nil, 44100, 2, nil},
+	}
+	for _, tt := range tests {
+		for _, chunk := range []int{4, 7, 4096} {
+			got := convert(t, tt.in, tt.rate, tt.chans, chunk)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s (leituras de %d bytes): saída = %v, esperado %v", tt.name, chunk, got, tt.want)
+			}
+		}
+	}
+}
+
+// A duração se mantém na conversão de taxas comuns
+func TestPCMConverterLength(t *testing.T) {
+	for _, rate := range []int{8000, 22050, 32000, 44100, 88200, 96000} {
+		in := make([]int16, rate) // 1s mono
+		frames := len(convert(t, in, rate, 1, 4096)) / 2
+		if frames < 47990 || frames > 48010 {
+			t.Errorf("1s a %dHz virou %d frames, esperado ~48000", rate, frames)
+		}
+	}
+}
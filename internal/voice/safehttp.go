@@ -0,0 +1,93 @@
+package voice
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress é retornado quando o link (ou um redirecionamento) aponta para
+// um endereço que não é público: a rede interna do host não pode ser sondada pelo bot
+var ErrBlockedAddress = errors.New("o link aponta para um endereço não público")
+
+// Redirecionamentos seguidos por download
+const maxRedirects = 5
+
+// Faixas fora da internet pública que o netip não classifica sozinho
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "Esta rede"
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // Atribuições de protocolo do IETF
+	netip.MustParsePrefix("198.18.0.0/15"), // Testes de desempenho
+	netip.MustParsePrefix("240.0.0.0/4"),   // Reservado (inclui broadcast)
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64: embute endereços IPv4 quaisquer
+	netip.MustParsePrefix("2002::/16"),     // 6to4: idem
+}
+
+// PublicHTTPClient é o cliente padrão do HTTPSource. Ele só conecta em endereços
+// públicos, checados no Dial depois da resolução de DNS (um nome que resolve para
+// 127.0.0.1 ou 169.254.169.254 é recusado), e revalida cada redirecionamento.
+var PublicHTTPClient = newPublicHTTPClient()
+
+func newPublicHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   dialPublicOnly,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Um proxy faria o Dial ir para ele e não para o destino, escapando da checagem
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport, CheckRedirect: checkRedirect}
+}
+
+// dialPublicOnly roda com o endereço já resolvido, logo antes de conectar
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	return nil
+}
+
+// isPublicAddr indica se o endereço é roteável na internet pública
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRedirect limita os redirecionamentos e revalida cada salto: só http/https, e
+// endereços IP literais passam pela mesma regra do Dial
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("redirecionamentos demais (máximo %d)", maxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return ErrInvalidURL
+	}
+	if ip, err := netip.ParseAddr(req.URL.Hostname()); err == nil && !isPublicAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package voice
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrNotSeekable é retornado ao pedir um offset para uma fonte que só toca do início
+var ErrNotSeekable = errors.New("fonte de áudio não permite seek")
+
+// errSource marca erros da fonte (abrir ou ler), que pulam só a faixa atual
+var errSource = errors.New("erro na fonte de áudio")
+
+// Frame é um frame de 20ms pronto para o player.
+// Fontes preenchem PCM (s16le intercalado, 48kHz, estéreo), Opus ou os dois;
+// o player só codifica quando não há pacote Opus ou o volume não é 100%.
+// Os slices só valem até a próxima chamada de ReadFrame.
+type Frame struct {
+	PCM  []int16
+	Opus []byte
+}
+
+// FrameReader entrega os frames de uma fonte em sequência (io.EOF no fim)
+type FrameReader interface {
+	ReadFrame() (Frame, error)
+	Close() error
+}
+
+// AudioSource é qualquer coisa que o player sabe tocar: faixa em memória,
+// arquivo lido do disco, URL ou gerador sintético.
+type AudioSource interface {
+	// Open começa a leitura no offset pedido. Fontes que não permitem seek
+	// só aceitam offset 0 (ErrNotSeekable nos demais).
+	Open(offset time.Duration) (FrameReader, error)
+	// Duration retorna a duração total (0 = desconhecida ou infinita)
+	Duration() time.Duration
+	// Seekable indica se Open aceita offset diferente de 0
+	Seekable() bool
+}
+
+// Open implementa AudioSource para a faixa em memória
+func (a *Audio) Open(offset time.Duration) (FrameReader, error) {
+	pos := int(offset / frameDuration)
+	if pos < 0 || pos > a.FrameCount() {
+		return nil, ErrSeekOutOfRange
+	}
+	return &audioReader{audio: a, pos: pos}, nil
+}
+
+// Seekable é sempre true: todos os frames estão na memória
+func (a *Audio) Seekable() bool {
+	return true
+}
+
+// audioReader percorre os frames de um Audio sem copiar nada
+type audioReader struct {
+	audio *Audio
+	pos   int
+}
+
+func (r *audioReader) ReadFrame() (Frame, error) {
+	if r.pos >= r.audio.FrameCount() {
+		return Frame{}, io.EOF
+	}
+
+	f := Frame{Opus: r.audio.Frames[r.pos]}
+	// Faixas passthrough não têm PCM: o player decodifica o Opus se precisar
+	if !r.audio.Passthrough {
+		f.PCM = r.audio.frame(r.pos)
+	}
+	r.pos++
+	return f, nil
+}
+
+func (r *audioReader) Close() error {
+	return nil
+}
+
+// pcmStreamReader corta um stream PCM s16le (48kHz, estéreo) em frames de 20ms.
+// O último frame é completado com silêncio.
+type pcmStreamReader struct {
+	stream io.ReadCloser
+	buf    []byte
+	pcm    []int16
+}
+
+func newPCMStreamReader(stream io.ReadCloser) *pcmStreamReader {
+	return &pcmStreamReader{
+		stream: stream,
+		buf:    make([]byte, frameSize*channels*2),
+		pcm:    make([]int16, frameSize*channels),
+	}
+}
+
+func (r *pcmStreamReader) ReadFrame() (Frame, error) {
+	n, err := io.ReadFull(r.stream, r.buf)
+	if err == io.EOF {
+		return Frame{}, io.EOF
+	}
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return Frame{}, fmt.Errorf("erro lendo stream de áudio: %w", err)
+	}
+
+	clear(r.buf[n:])
+	for i := range r.pcm {
+		r.pcm[i] = int16(binary.LittleEndian.Uint16(r.buf[i*2:]))
+	}
+	return Frame{PCM: r.pcm}, nil
+}
+
+func (r *pcmStreamReader) Close() error {
+	return r.stream.Close()
+}
+
+// skipFrames descarta frames até chegar no offset (seek em fontes sequenciais)
+func skipFrames(r FrameReader, offset time.Duration) error {
+	for n := int(offset / frameDuration); n > 0; n-- {
+		if _, err := r.ReadFrame(); err != nil {
+			if err == io.EOF {
+				return ErrSeekOutOfRange
+			}
+			return err
+		}
+	}
+	return nil
+}
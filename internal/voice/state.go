@@ -0,0 +1,153 @@
+package voice
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// State é a fase do ciclo de vida de uma sessão de voz
+type State int
+
+const (
+	StateConnecting   State = iota // Join abrindo a conexão de voz
+	StateReady                     // Conectada, sem player
+	StatePlaying                   // Player tocando
+	StatePaused                    // Player pausado na mesma posição
+	StateMigrating                 // Servidor de voz mudando (Voice Server Update)
+	StateReconnecting              // Conexão perdida sendo reaberta
+	StateDraining                  // Lazy Exit: termina a música atual e sai do canal
+	StateClosed                    // Fora do canal; a sessão não é mais usada
+)
+
+var stateNames = map[State]string{
+	StateConnecting:   "conectando",
+	StateReady:        "pronta",
+	StatePlaying:      "tocando",
+	StatePaused:       "pausada",
+	StateMigrating:    "migrando",
+	StateReconnecting: "reconectando",
+	StateDraining:     "encerrando",
+	StateClosed:       "fechada",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// ErrInvalidTransition é retornado quando a sessão não pode ir do estado atual para o pedido
+var ErrInvalidTransition = errors.New("transição de estado inválida")
+
+// transitions lista os destinos válidos a partir de cada estado. Migrating e
+// Reconnecting são temporários: ao terminar voltam para a fase do player.
+var transitions = map[State][]State{
+	StateConnecting:   {StateReady, StateClosed},
+	StateReady:        {StatePlaying, StateMigrating, StateReconnecting, StateClosed},
+	StatePlaying:      {StatePaused, StateDraining, StateReady, StateMigrating, StateReconnecting, StateClosed},
+	StatePaused:       {StatePlaying, StateDraining, StateReady, StateMigrating, StateReconnecting, StateClosed},
+	StateDraining:     {StatePlaying, StateReady, StateMigrating, StateReconnecting, StateClosed},
+	StateMigrating:    {StateReady, StatePlaying, StatePaused, StateDraining, StateReconnecting, StateClosed},
+	StateReconnecting: {StateReady, StatePlaying, StatePaused, StateDraining, StateClosed},
+	StateClosed:       nil,
+}
+
+// CanTransition indica se a sessão pode ir de from para to
+func CanTransition(from, to State) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// temporary indica os estados da conexão que ficam por cima da fase do player
+func (s State) temporary() bool {
+	return s == StateMigrating || s == StateReconnecting
+}
+
+// StateEvent é uma mudança de estado de uma sessão
+type StateEvent struct {
+	GuildID string
+	From    State
+	To      State
+	At      time.Time
+}
+
+// State retorna o estado atual da sessão
+func (sess *Session) State() State {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.state
+}
+
+// transitionLocked valida e aplica a transição, publicando o evento. Ir para o
+// estado atual não faz nada. Deve ser chamado com sess.mu travado.
+func (sess *Session) transitionLocked(to State) error {
+	from := sess.state
+	if from == to {
+		return nil
+	}
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+
+	sess.state = to
+	if !to.temporary() {
+		sess.phase = to
+	}
+	if to == StateClosed {
+		close(sess.closed)
+	}
+	sess.owner.publishState(StateEvent{GuildID: sess.GuildID, From: from, To: to, At: sess.owner.clock.Now()})
+	return nil
+}
+
+// setPhaseLocked muda a fase do player (Ready, Playing, Paused, Draining). Durante
+// uma migração ou reconexão a mudança fica guardada e aparece quando a conexão voltar.
+// Deve ser chamado com sess.mu travado.
+func (sess *Session) setPhaseLocked(to State) error {
+	if !sess.state.temporary() {
+		return sess.transitionLocked(to)
+	}
+	if sess.phase != to && !CanTransition(sess.phase, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, sess.phase, to)
+	}
+	sess.phase = to
+	return nil
+}
+
+// begin entra em um estado temporário (Migrating ou Reconnecting)
+func (sess *Session) begin(temporary State) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.state == temporary {
+		return fmt.Errorf("%w: já %s", ErrInvalidTransition, temporary)
+	}
+	return sess.transitionLocked(temporary)
+}
+
+// end sai do estado temporário de volta para a fase do player. Retorna false se a
+// sessão já não estava nele (outra transição aconteceu no meio).
+func (sess *Session) end(temporary State) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.state != temporary {
+		return false
+	}
+	return sess.transitionLocked(sess.phase) == nil
+}
+
+// Subscribe assina as mudanças de estado de todas as sessões do manager, na ordem
+// em que acontecem. Um assinante lento (buffer cheio) perde eventos em vez de travar
+// o player. cancel encerra a assinatura e fecha o canal.
+func (m *Manager) Subscribe(buffer int) (events <-chan StateEvent, cancel func()) {
+	return m.states.subscribe(buffer)
+}
+
+// publishState entrega o evento aos assinantes sem bloquear (chamado com sess.mu travado)
+func (m *Manager) publishState(ev StateEvent) {
+	m.log.Debug("Estado da sessão de voz", "guild_id", ev.GuildID, "from", ev.From, "to", ev.To)
+	if m.states.publish(ev) > 0 {
+		m.log.Warn("Assinante de estados lento, evento descartado", "guild_id", ev.GuildID, "to", ev.To)
+	}
+}
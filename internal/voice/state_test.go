@@ -0,0 +1,286 @@
+package voice
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var quietLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// collect lê os eventos até a sessão fechar
+func collect(t *testing.T, events <-chan StateEvent, timeout time.Duration) []StateEvent {
+	t.Helper()
+	var got []StateEvent
+	deadline := time.After(timeout)
+	for {
+		select {
+		case ev := <-events:
+			got = append(got, ev)
+			if ev.To == StateClosed {
+				return got
+			}
+		case <-deadline:
+			t.Fatalf("timeout (%s) esperando Closed, eventos: %v", timeout, got)
+		}
+	}
+}
+
+// checkEvents confere que cada evento é uma transição válida que parte de onde o anterior parou
+func checkEvents(t *testing.T, events []StateEvent) {
+	t.Helper()
+	from := StateConnecting
+	for i, ev := range events {
+		if ev.From != from {
+			t.Errorf("evento %d sai de %s, mas a sessão estava em %s", i, ev.From, from)
+		}
+		if !CanTransition(ev.From, ev.To) {
+			t.Errorf("evento %d: transição inválida %s -> %s", i, ev.From, ev.To)
+		}
+		from = ev.To
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to State
+		want     bool
+	}{
+		{StateConnecting, StateReady, true},
+		{StateConnecting, StatePlaying, false},
+		{StateReady, StatePlaying, true},
+		{StateReady, StatePaused, false},
+		{StateReady, StateDraining, false},
+		{StatePlaying, StatePaused, true},
+		{StatePaused, StateDraining, true},
+		{StateDraining, StatePaused, false},
+		{StateMigrating, StatePaused, true},
+		{StateReconnecting, StateMigrating, false},
+		{StateClosed, StateReady, false},
+		{StateClosed, StateConnecting, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, esperado %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestStateEvents(t *testing.T) {
+	dialer := &fakeDialer{}
+	m := NewManager(WithDialer(dialer.dial), WithLogger(quietLogger), WithTimeouts(Timeouts{
+		Ready:      time.Second,
+		Migration:  time.Second,
+		LeaveDelay: 10 * time.Millisecond,
+	}))
+	events, cancel := m.Subscribe(64)
+	defer cancel()
+
+	sess, err := m.Join("guild", "canal")
+	if err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	sess.PlayLoop(toneItem(600*time.Millisecond, 1), 0)
+	eventually(t, 3*time.Second, "primeiros frames", func() bool { return len(dialer.transport(0).audio()) > 0 })
+	if !sess.Pause() {
+		t.Fatal("Pause tocando deveria funcionar")
+	}
+	if sess.Pause() {
+		t.Error("Pause já pausado deveria falhar")
+	}
+	if !sess.Resume() {
+		t.Fatal("Resume pausado deveria funcionar")
+	}
+
+	got := collect(t, events, 5*time.Second)
+	checkEvents(t, got)
+
+	want := []State{StateReady, StatePlaying, StatePaused, StatePlaying, StateReady, StateClosed}
+	if len(got) != len(want) {
+		t.Fatalf("eventos = %v, esperado as transições para %v", got, want)
+	}
+	for i, ev := range got {
+		if ev.To != want[i] || ev.GuildID != "guild" {
+			t.Errorf("evento %d = %+v, esperado -> %s", i, ev, want[i])
+		}
+	}
+}
+
+func TestStateMigrationKeepsPhase(t *testing.T) {
+	sess, dialer := joinFake(t, WithLogger(quietLogger), WithTimeouts(Timeouts{
+		Ready:      time.Second,
+		Migration:  time.Second,
+		LeaveDelay: time.Second,
+	}))
+	dialer.transport(0).setMigrate(200 * time.Millisecond)
+	sess.PlayLoop(toneItem(time.Second, 0), 0)
+
+	serverUpdate(sess, "novo")
+	if got := sess.State(); got != StateMigrating {
+		t.Fatalf("estado = %s, esperado migrando", got)
+	}
+
+	// Pausa durante a migração: o estado visível continua Migrating até ela acabar
+	if !sess.Pause() {
+		t.Fatal("Pause durante a migração deveria funcionar")
+	}
+	if !sess.IsPaused() || !sess.IsPlaying() || sess.State() != StateMigrating {
+		t.Errorf("pausado=%v tocando=%v estado=%s durante a migração", sess.IsPaused(), sess.IsPlaying(), sess.State())
+	}
+	eventually(t, time.Second, "fim da migração", func() bool { return sess.State() == StatePaused })
+}
+
+func TestStateDrain(t *testing.T) {
+	sess, _ := joinFake(t, WithLogger(quietLogger))
+	if sess.Drain() {
+		t.Error("Drain sem nada tocando deveria falhar")
+	}
+
+	sess.PlayLoop(toneItem(time.Second, 0), 0)
+	sess.Pause()
+	if !sess.Drain() {
+		t.Fatal("Drain pausado deveria funcionar")
+	}
+	// O Lazy Exit retoma a música para ela terminar
+	if sess.IsPaused() || !sess.IsDraining() || sess.State() != StateDraining {
+		t.Errorf("pausado=%v encerrando=%v estado=%s depois do Drain", sess.IsPaused(), sess.IsDraining(), sess.State())
+	}
+	if sess.Pause() {
+		t.Error("Pause durante o Lazy Exit deveria falhar")
+	}
+}
+
+func TestJoinConcurrent(t *testing.T) {
+	dialer := &fakeDialer{}
+	m := NewManager(WithDialer(dialer.dial), WithLogger(quietLogger))
+	defer m.Leave("guild")
+
+	sessions := make([]*Session, 8)
+	var wg sync.WaitGroup
+	for i := range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sess, err := m.Join("guild", "canal")
+			if err != nil {
+				t.Errorf("join: %v", err)
+			}
+			sessions[i] = sess
+		}()
+	}
+	wg.Wait()
+
+	if dialer.dials() != 1 {
+		t.Errorf("dials = %d, esperado 1 para Joins concorrentes", dialer.dials())
+	}
+	for _, sess := range sessions {
+		if sess != sessions[0] {
+			t.Fatal("Joins concorrentes retornaram sessões diferentes")
+		}
+	}
+
+	if _, err := m.Join("guild", "outro"); err != nil {
+		t.Fatalf("join em outro canal: %v", err)
+	}
+	if got := sessions[0].ChannelID(); got != "outro" {
+		t.Errorf("ChannelID = %q, esperado outro", got)
+	}
+}
+
+func TestJoinDialError(t *testing.T) {
+	failed := errors.New("sem rota")
+	m := NewManager(WithLogger(quietLogger), WithDialer(func(guildID, channelID string) (VoiceTransport, error) {
+		return nil, failed
+	}))
+	events, cancel := m.Subscribe(8)
+	defer cancel()
+
+	if _, err := m.Join("guild", "canal"); !errors.Is(err, failed) {
+		t.Fatalf("join = %v, esperado o erro da conexão", err)
+	}
+	if m.GetSession("guild") != nil {
+		t.Error("sessão com falha na conexão continuou registrada")
+	}
+	if got := collect(t, events, time.Second); len(got) != 1 || got[0].From != StateConnecting {
+		t.Errorf("eventos = %v, esperado conectando -> fechada", got)
+	}
+}
+
+// TestSessionConcurrentAccess mexe na sessão de várias goroutines ao mesmo tempo.
+// Rodar com -race: o detector aponta acessos sem lock, e os eventos precisam
+// formar uma sequência válida e contínua.
+func TestSessionConcurrentAccess(t *testing.T) {
+	sess, _ := joinFake(t, WithLogger(quietLogger), WithTimeouts(Timeouts{
+		Ready:      time.Second,
+		Migration:  30 * time.Millisecond,
+		LeaveDelay: time.Second,
+	}))
+	m := sess.owner
+	events, cancel := m.Subscribe(4096)
+	defer cancel()
+	sess.PlayLoop(toneItem(300*time.Millisecond, 0), 0)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	hammer := func(every time.Duration, op func(n int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-stop:
+					return
+				case <-time.After(every):
+				}
+				op(n)
+			}
+		}()
+	}
+
+	hammer(3*time.Millisecond, func(int) { sess.Pause() })
+	hammer(5*time.Millisecond, func(int) { sess.Resume() })
+	hammer(7*time.Millisecond, func(n int) { sess.SetVolume(n % 200) })
+	hammer(11*time.Millisecond, func(int) { sess.Skip() })
+	hammer(13*time.Millisecond, func(int) { sess.Enqueue(toneItem(100*time.Millisecond, 1)) })
+	hammer(17*time.Millisecond, func(n int) { sess.SetLoopMode(LoopMode(n % 4)) })
+	hammer(19*time.Millisecond, func(int) {
+		m.HandleServerUpdate(&discordgo.VoiceServerUpdate{GuildID: sess.GuildID, Endpoint: "novo"})
+	})
+	hammer(time.Millisecond, func(int) {
+		sess.State()
+		sess.Progress()
+		sess.ChannelID()
+		sess.IsPlaying()
+		sess.IsPaused()
+		sess.IsDraining()
+		sess.IsMigrating()
+		sess.IsReconnecting()
+		sess.GetConnection()
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := m.Reconnect(sess.GuildID); err != nil {
+			t.Errorf("reconexão: %v", err)
+		}
+	}()
+
+	time.Sleep(time.Second)
+	close(stop)
+	wg.Wait()
+	m.Leave(sess.GuildID)
+
+	got := collect(t, events, 5*time.Second)
+	if got[0].From != StateReady {
+		t.Errorf("primeiro evento sai de %s, esperado pronta", got[0].From)
+	}
+	checkEvents(t, append([]StateEvent{{From: StateConnecting, To: StateReady}}, got...))
+	if sess.State() != StateClosed || sess.IsPlaying() {
+		t.Errorf("estado = %s, tocando = %v depois do Leave", sess.State(), sess.IsPlaying())
+	}
+}
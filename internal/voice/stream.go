@@ -0,0 +1,67 @@
+package voice
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// FileSource toca um arquivo local lendo do disco sob demanda, sem carregar a
+// faixa inteira na memória. O seek decodifica e descarta até o offset.
+type FileSource struct {
+	Path     string
+	Decoder  Decoder
+	duration time.Duration
+}
+
+// NewFileSource cria a fonte para o arquivo (duration 0 = desconhecida)
+func NewFileSource(path string, duration time.Duration, decoder Decoder) *FileSource {
+	return &FileSource{Path: path, Decoder: decoder, duration: duration}
+}
+
+func (f *FileSource) Open(offset time.Duration) (FrameReader, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo de áudio: %w", err)
+	}
+
+	reader, err := openDecoded(file, f.Decoder)
+	if err != nil {
+		return nil, err
+	}
+	if err := skipFrames(reader, offset); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+func (f *FileSource) Duration() time.Duration { return f.duration }
+
+func (f *FileSource) Seekable() bool { return true }
+
+// openDecoded passa o arquivo pelo decoder e devolve os frames PCM.
+// Fechar o reader fecha também o arquivo de origem.
+func openDecoded(src io.ReadCloser, decoder Decoder) (FrameReader, error) {
+	stream, err := decoder.Decode(src)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("erro ao decodificar áudio (%s): %w", decoder.Name(), err)
+	}
+	return newPCMStreamReader(&decodedStream{ReadCloser: stream, src: src}), nil
+}
+
+// decodedStream fecha o stream decodificado e o arquivo de origem juntos
+type decodedStream struct {
+	io.ReadCloser
+	src io.Closer
+}
+
+func (d *decodedStream) Close() error {
+	err := d.ReadCloser.Close()
+	if srcErr := d.src.Close(); err == nil {
+		err = srcErr
+	}
+	return err
+}
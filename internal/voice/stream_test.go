@@ -0,0 +1,93 @@
+package voice
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeWAV grava um WAV 48kHz estéreo com a duração pedida
+func writeWAV(t *testing.T, dir, name string, d time.Duration) string {
+	t.Helper()
+	samples := int(d.Seconds() * frameRate)
+	pcm := make([]int16, samples*2)
+	for i := range pcm {
+		pcm[i] = int16(i % 2000) // Serra audível, para a medição de loudness
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, wavFile(fmtChunk(1, 2, frameRate, 16, false), wavChunk{"data", le16(pcm...)}), 0o644); err != nil {
+		t.Fatalf("gravando %s: %v", name, err)
+	}
+	return path
+}
+
+func TestFileSourceSeek(t *testing.T) {
+	src := NewFileSource(writeWAV(t, t.TempDir(), "faixa.wav", time.Second), time.Second, nativeDecoder{})
+	if !src.Seekable() || src.Duration() != time.Second {
+		t.Fatalf("Seekable = %v, Duration = %s", src.Seekable(), src.Duration())
+	}
+
+	tests := []struct {
+		offset time.Duration
+		want   int // Frames até o fim
+	}{
+		{0, 50},
+		{300 * time.Millisecond, 35},
+		{980 * time.Millisecond, 1},
+	}
+	for _, tt := range tests {
+		n, err := countFrames(offsetSource{src, tt.offset})
+		if err != nil {
+			t.Errorf("offset %s: %v", tt.offset, err)
+			continue
+		}
+		if n != tt.want {
+			t.Errorf("offset %s: %d frames até o fim, esperado %d", tt.offset, n, tt.want)
+		}
+	}
+
+	if _, err := src.Open(2 * time.Second); !errors.Is(err, ErrSeekOutOfRange) {
+		t.Errorf("offset além do fim: err = %v, esperado ErrSeekOutOfRange", err)
+	}
+	if _, err := NewFileSource(filepath.Join(t.TempDir(), "nada.wav"), 0, nativeDecoder{}).Open(0); err == nil {
+		t.Error("arquivo inexistente deveria falhar")
+	}
+}
+
+// offsetSource abre a fonte sempre no mesmo offset (para o countFrames)
+type offsetSource struct {
+	AudioSource
+	offset time.Duration
+}
+
+func (o offsetSource) Open(time.Duration) (FrameReader, error) {
+	return o.AudioSource.Open(o.offset)
+}
+
+// Faixas decodificadas da biblioteca tocam do disco, com duração e loudness medidas no carregamento
+func TestLoadLibraryStreamsFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	writeWAV(t, dir, "faixa-longa.wav", 2*time.Second)
+	os.WriteFile(filepath.Join(dir, "quebrada.mp3"), []byte("não é mp3"), 0o644)
+
+	lib, err := LoadLibrary(dir, nativeDecoder{})
+	if err != nil {
+		t.Fatalf("LoadLibrary: %v", err)
+	}
+	if len(lib.All()) != 1 {
+		t.Fatalf("%d faixas, esperado só a WAV válida", len(lib.All()))
+	}
+
+	track := lib.Get("faixa-longa")
+	if _, ok := track.Source.(*FileSource); !ok {
+		t.Fatalf("fonte = %T, esperado *FileSource", track.Source)
+	}
+	if track.Duration != 2*time.Second || track.Source.Duration() != track.Duration {
+		t.Errorf("duração = %s (fonte %s), esperado 2s", track.Duration, track.Source.Duration())
+	}
+	if track.Loudness == 0 {
+		t.Error("loudness não foi medida")
+	}
+}
@@ -0,0 +1,99 @@
+package voice
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// VoiceTransport é a conexão de voz usada pela Session: tudo o que o player, a
+// reconexão e o manager precisam da *discordgo.VoiceConnection. Nos testes ela é
+// trocada por uma conexão em memória.
+type VoiceTransport interface {
+	// Ready indica se a conexão pode enviar áudio
+	Ready() bool
+	// Generation muda cada vez que a conexão abre um UDP novo, como quando o discordgo
+	// troca de servidor sozinho. Ao contrário do Ready ela só cresce, então quem compara
+	// duas gerações não precisa ter visto a conexão cair.
+	Generation() uint64
+	// OpusSend é o canal de pacotes Opus (nil enquanto a conexão não abriu o UDP).
+	// Quem envia não deve bloquear nele: o canal enche quando o UDP trava.
+	OpusSend() chan<- []byte
+	Speaking(speaking bool) error
+	ChangeChannel(channelID string, mute, deaf bool) error
+	Disconnect() error
+}
+
+// VoiceDialer abre uma conexão de voz no canal (usado no Join e na reconexão)
+type VoiceDialer func(guildID, channelID string) (VoiceTransport, error)
+
+// DiscordDialer conecta pelo gateway do Discord, mudo e surdo como o bot sempre entrou
+func DiscordDialer(s *discordgo.Session) VoiceDialer {
+	return func(guildID, channelID string) (VoiceTransport, error) {
+		vc, err := s.ChannelVoiceJoin(guildID, channelID, false, true)
+		if err != nil {
+			return nil, err
+		}
+		return &discordTransport{vc: vc}, nil
+	}
+}
+
+// discordTransport adapta a *discordgo.VoiceConnection. Ready e OpusSend são
+// campos escritos pelas goroutines do discordgo, então são lidos com o lock dela.
+type discordTransport struct {
+	vc *discordgo.VoiceConnection
+
+	mu  sync.Mutex
+	udp reflect.Value // Última conexão UDP vista, mantida viva para o endereço não ser reaproveitado
+	gen uint64
+}
+
+func (t *discordTransport) Ready() bool {
+	t.vc.RLock()
+	defer t.vc.RUnlock()
+	return t.vc.Ready
+}
+
+func (t *discordTransport) OpusSend() chan<- []byte {
+	t.vc.RLock()
+	defer t.vc.RUnlock()
+	return t.vc.OpusSend
+}
+
+func (t *discordTransport) Speaking(speaking bool) error {
+	return t.vc.Speaking(speaking)
+}
+
+func (t *discordTransport) ChangeChannel(channelID string, mute, deaf bool) error {
+	return t.vc.ChangeChannel(channelID, mute, deaf)
+}
+
+func (t *discordTransport) Disconnect() error {
+	return t.vc.Disconnect()
+}
+
+// Generation conta as conexões UDP diferentes vistas. O discordgo não expõe nada
+// por conexão (o OpusSend é reaproveitado), então o udpConn, que ele cria a cada
+// open e zera no Close, é lido por reflexão.
+func (t *discordTransport) Generation() uint64 {
+	t.vc.RLock()
+	var udp reflect.Value
+	if field := reflect.ValueOf(t.vc).Elem().FieldByName("udpConn"); !field.IsNil() {
+		udp = field.Elem()
+	}
+	t.vc.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if udp.IsValid() && (!t.udp.IsValid() || udp.UnsafeAddr() != t.udp.UnsafeAddr()) {
+		t.udp = udp
+		t.gen++
+	}
+	return t.gen
+}
+
+// transportReady indica se a conexão existe e pode receber pacotes agora
+func transportReady(vc VoiceTransport) bool {
+	return vc != nil && vc.Ready() && vc.OpusSend() != nil
+}
@@ -4,10 +4,12 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
 	"hakari-bot/internal/bot"
 	"hakari-bot/internal/logger"
+	"hakari-bot/internal/settings"
 	"hakari-bot/internal/voice"
 
 	"github.com/bwmarrin/discordgo"
@@ -23,12 +25,27 @@ func main() {
 		slog.Warn("Arquivo .env não encontrado, usando vars do sistema.")
 	}
 
-	// 2.5 Carrega áudio para memória
-	if err := voice.LoadAudio("./tuca-donka.mp3"); err != nil {
-		slog.Error("Erro fatal ao carregar áudio", "error", err)
+	// 2.4 Escolhe o decoder de áudio (auto = nativo em Go, com ffmpeg para formatos extras)
+	decoder, err := voice.NewDecoder(os.Getenv("DECODER"))
+	if err != nil {
+		slog.Error("Erro ao configurar decoder", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("Áudio carregado na memória com sucesso!")
+	slog.Info("Decoder de áudio configurado", "decoder", decoder.Name())
+
+	// 2.45 Alvo da normalização de loudness (LUFS negativo, "off" desliga)
+	targetLUFS := voice.DefaultTargetLUFS
+	if target := os.Getenv("TARGET_LUFS"); target != "" {
+		if target == "off" {
+			targetLUFS = 0
+		} else if lufs, err := strconv.ParseFloat(target, 64); err == nil && lufs <= 0 {
+			targetLUFS = lufs
+		} else {
+			slog.Error("TARGET_LUFS inválido, use um valor como -14 ou off", "value", target)
+			os.Exit(1)
+		}
+	}
+	slog.Info("Normalização de loudness configurada", "target_lufs", targetLUFS)
 
 	token := os.Getenv("TOKEN")
 	if token == "" {
@@ -47,8 +64,38 @@ func main() {
 	// GuildVoiceStates é necessário para saber quem está nos canais
 	s.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildVoiceStates | discordgo.IntentsGuildMessages
 
+	// 4.5 Abre o armazenamento de configurações por servidor
+	settingsPath := os.Getenv("SETTINGS_PATH")
+	if settingsPath == "" {
+		settingsPath = "./data/settings.json"
+	}
+	store, err := settings.Open(settingsPath)
+	if err != nil {
+		slog.Error("Erro ao carregar configurações", "error", err)
+		os.Exit(1)
+	}
+
+	// 4.6 Cria o manager de voz com a sessão do Discord, o decoder e o alvo de loudness escolhidos
+	manager := voice.NewManager(
+		voice.WithDiscord(s),
+		voice.WithDecoder(decoder),
+		voice.WithTargetLUFS(targetLUFS),
+		voice.WithLogger(slog.Default()),
+	)
+
+	// 4.7 Carrega a biblioteca de faixas para memória (decodifica e codifica em Opus uma única vez)
+	audioDir := os.Getenv("AUDIO_DIR")
+	if audioDir == "" {
+		audioDir = "./audio"
+	}
+	if err := manager.LoadLibrary(audioDir); err != nil {
+		slog.Error("Erro fatal ao carregar áudio", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Biblioteca carregada na memória com sucesso!", "tracks", len(manager.Library().All()))
+
 	// 5. Injeta handlers
-	b := bot.NewBot()
+	b := bot.NewBot(store, manager)
 	s.AddHandler(b.InteractionHandler)
 	s.AddHandler(b.VoiceStateUpdateHandler)
 	s.AddHandler(b.VoiceServerUpdateHandler)